@@ -0,0 +1,298 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+)
+
+// Currency describes a single currency as defined by ISO 4217
+type Currency struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"currency"`
+
+	// Code - alphabetic ISO 4217 code, i.e. 'EUR'
+	Code string `json:"code" xml:"code"`
+
+	// Numeric - numeric ISO 4217 code, i.e. '978'
+	Numeric string `json:"numeric" xml:"numeric"`
+
+	// Name - english name of the currency
+	Name string `json:"name" xml:"name"`
+
+	// MinorUnits - number of digits after the decimal separator
+	MinorUnits int32 `json:"minorUnits" xml:"minorUnits"`
+
+	// Symbol - commonly used currency symbol, might be empty
+	Symbol string `json:"symbol,omitempty" xml:"symbol,omitempty"`
+
+	// Active - false for historic currencies that are no longer in use
+	Active bool `json:"active" xml:"active"`
+}
+
+// Embedded ISO 4217 registry. Funds and precious metals without minor units are not included.
+var currencies = []Currency{
+	active("AED", "784", "UAE Dirham", 2, "د.إ"),
+	active("AFN", "971", "Afghani", 2, "؋"),
+	active("ALL", "008", "Lek", 2, "L"),
+	active("AMD", "051", "Armenian Dram", 2, "֏"),
+	historic("ANG", "532", "Netherlands Antillean Guilder", 2, "ƒ"),
+	active("AOA", "973", "Kwanza", 2, "Kz"),
+	active("ARS", "032", "Argentine Peso", 2, "$"),
+	historic("ATS", "040", "Schilling", 2, "S"),
+	active("AUD", "036", "Australian Dollar", 2, "A$"),
+	active("AWG", "533", "Aruban Florin", 2, "ƒ"),
+	active("AZN", "944", "Azerbaijan Manat", 2, "₼"),
+	active("BAM", "977", "Convertible Mark", 2, "KM"),
+	active("BBD", "052", "Barbados Dollar", 2, "$"),
+	active("BDT", "050", "Taka", 2, "৳"),
+	historic("BEF", "056", "Belgian Franc", 0, "fr."),
+	historic("BGN", "975", "Bulgarian Lev", 2, "лв"),
+	active("BHD", "048", "Bahraini Dinar", 3, ".د.ب"),
+	active("BIF", "108", "Burundi Franc", 0, "FBu"),
+	active("BMD", "060", "Bermudian Dollar", 2, "$"),
+	active("BND", "096", "Brunei Dollar", 2, "$"),
+	active("BOB", "068", "Boliviano", 2, "Bs."),
+	active("BOV", "984", "Mvdol", 2, ""),
+	active("BRL", "986", "Brazilian Real", 2, "R$"),
+	active("BSD", "044", "Bahamian Dollar", 2, "$"),
+	active("BTN", "064", "Ngultrum", 2, "Nu."),
+	active("BWP", "072", "Pula", 2, "P"),
+	active("BYN", "933", "Belarusian Ruble", 2, "Br"),
+	historic("BYR", "974", "Belarusian Ruble", 0, "Br"),
+	active("BZD", "084", "Belize Dollar", 2, "$"),
+	active("CAD", "124", "Canadian Dollar", 2, "$"),
+	active("CDF", "976", "Congolese Franc", 2, "FC"),
+	active("CHE", "947", "WIR Euro", 2, ""),
+	active("CHF", "756", "Swiss Franc", 2, "CHF"),
+	active("CHW", "948", "WIR Franc", 2, ""),
+	active("CLF", "990", "Unidad de Fomento", 4, "UF"),
+	active("CLP", "152", "Chilean Peso", 0, "$"),
+	active("CNY", "156", "Yuan Renminbi", 2, "¥"),
+	active("COP", "170", "Colombian Peso", 2, "$"),
+	active("COU", "970", "Unidad de Valor Real", 2, ""),
+	active("CRC", "188", "Costa Rican Colon", 2, "₡"),
+	active("CUC", "931", "Peso Convertible", 2, "$"),
+	active("CUP", "192", "Cuban Peso", 2, "$"),
+	active("CVE", "132", "Cabo Verde Escudo", 2, "$"),
+	historic("CYP", "196", "Cyprus Pound", 2, "£"),
+	active("CZK", "203", "Czech Koruna", 2, "Kč"),
+	historic("DEM", "276", "Deutsche Mark", 2, "DM"),
+	active("DJF", "262", "Djibouti Franc", 0, "Fdj"),
+	active("DKK", "208", "Danish Krone", 2, "kr"),
+	active("DOP", "214", "Dominican Peso", 2, "$"),
+	active("DZD", "012", "Algerian Dinar", 2, "دج"),
+	historic("EEK", "233", "Kroon", 2, "kr"),
+	active("EGP", "818", "Egyptian Pound", 2, "£"),
+	active("ERN", "232", "Nakfa", 2, "Nfk"),
+	historic("ESP", "724", "Spanish Peseta", 0, "₧"),
+	active("ETB", "230", "Ethiopian Birr", 2, "Br"),
+	active("EUR", "978", "Euro", 2, "€"),
+	historic("FIM", "246", "Markka", 2, "mk"),
+	active("FJD", "242", "Fiji Dollar", 2, "$"),
+	active("FKP", "238", "Falkland Islands Pound", 2, "£"),
+	historic("FRF", "250", "French Franc", 2, "F"),
+	active("GBP", "826", "Pound Sterling", 2, "£"),
+	active("GEL", "981", "Lari", 2, "₾"),
+	active("GHS", "936", "Ghana Cedi", 2, "₵"),
+	active("GIP", "292", "Gibraltar Pound", 2, "£"),
+	active("GMD", "270", "Dalasi", 2, "D"),
+	active("GNF", "324", "Guinean Franc", 0, "FG"),
+	historic("GRD", "300", "Drachma", 0, "₯"),
+	active("GTQ", "320", "Quetzal", 2, "Q"),
+	active("GYD", "328", "Guyana Dollar", 2, "$"),
+	active("HKD", "344", "Hong Kong Dollar", 2, "$"),
+	active("HNL", "340", "Lempira", 2, "L"),
+	historic("HRK", "191", "Kuna", 2, "kn"),
+	active("HTG", "332", "Gourde", 2, "G"),
+	active("HUF", "348", "Forint", 2, "Ft"),
+	active("IDR", "360", "Rupiah", 2, "Rp"),
+	historic("IEP", "372", "Irish Pound", 2, "£"),
+	active("ILS", "376", "New Israeli Sheqel", 2, "₪"),
+	active("INR", "356", "Indian Rupee", 2, "₹"),
+	active("IQD", "368", "Iraqi Dinar", 3, "ع.د"),
+	active("IRR", "364", "Iranian Rial", 2, "﷼"),
+	active("ISK", "352", "Iceland Krona", 0, "kr"),
+	historic("ITL", "380", "Italian Lira", 0, "₤"),
+	active("JMD", "388", "Jamaican Dollar", 2, "$"),
+	active("JOD", "400", "Jordanian Dinar", 3, "د.ا"),
+	active("JPY", "392", "Yen", 0, "¥"),
+	active("KES", "404", "Kenyan Shilling", 2, "KSh"),
+	active("KGS", "417", "Som", 2, "с"),
+	active("KHR", "116", "Riel", 2, "៛"),
+	active("KMF", "174", "Comorian Franc", 0, "CF"),
+	active("KPW", "408", "North Korean Won", 2, "₩"),
+	active("KRW", "410", "Won", 0, "₩"),
+	active("KWD", "414", "Kuwaiti Dinar", 3, "د.ك"),
+	active("KYD", "136", "Cayman Islands Dollar", 2, "$"),
+	active("KZT", "398", "Tenge", 2, "₸"),
+	active("LAK", "418", "Lao Kip", 2, "₭"),
+	active("LBP", "422", "Lebanese Pound", 2, "ل.ل"),
+	active("LKR", "144", "Sri Lanka Rupee", 2, "Rs"),
+	active("LRD", "430", "Liberian Dollar", 2, "$"),
+	active("LSL", "426", "Loti", 2, "L"),
+	historic("LTL", "440", "Lithuanian Litas", 2, "Lt"),
+	historic("LVL", "428", "Latvian Lats", 2, "Ls"),
+	active("LYD", "434", "Libyan Dinar", 3, "ل.د"),
+	active("MAD", "504", "Moroccan Dirham", 2, "د.م."),
+	active("MDL", "498", "Moldovan Leu", 2, "L"),
+	active("MGA", "969", "Malagasy Ariary", 2, "Ar"),
+	active("MKD", "807", "Denar", 2, "ден"),
+	active("MMK", "104", "Kyat", 2, "K"),
+	active("MNT", "496", "Tugrik", 2, "₮"),
+	active("MOP", "446", "Pataca", 2, "MOP$"),
+	historic("MRO", "478", "Ouguiya", 2, "UM"),
+	active("MRU", "929", "Ouguiya", 2, "UM"),
+	historic("MTL", "470", "Maltese Lira", 2, "₤"),
+	active("MUR", "480", "Mauritius Rupee", 2, "₨"),
+	active("MVR", "462", "Rufiyaa", 2, "Rf"),
+	active("MWK", "454", "Malawi Kwacha", 2, "MK"),
+	active("MXN", "484", "Mexican Peso", 2, "$"),
+	active("MXV", "979", "Mexican Unidad de Inversion (UDI)", 2, ""),
+	active("MYR", "458", "Malaysian Ringgit", 2, "RM"),
+	active("MZN", "943", "Mozambique Metical", 2, "MT"),
+	active("NAD", "516", "Namibia Dollar", 2, "$"),
+	active("NGN", "566", "Naira", 2, "₦"),
+	active("NIO", "558", "Cordoba Oro", 2, "C$"),
+	historic("NLG", "528", "Netherlands Guilder", 2, "ƒ"),
+	active("NOK", "578", "Norwegian Krone", 2, "kr"),
+	active("NPR", "524", "Nepalese Rupee", 2, "₨"),
+	active("NZD", "554", "New Zealand Dollar", 2, "$"),
+	active("OMR", "512", "Rial Omani", 3, "ر.ع."),
+	active("PAB", "590", "Balboa", 2, "B/."),
+	active("PEN", "604", "Sol", 2, "S/"),
+	active("PGK", "598", "Kina", 2, "K"),
+	active("PHP", "608", "Philippine Peso", 2, "₱"),
+	active("PKR", "586", "Pakistan Rupee", 2, "₨"),
+	active("PLN", "985", "Zloty", 2, "zł"),
+	historic("PTE", "620", "Portuguese Escudo", 0, "$"),
+	active("PYG", "600", "Guarani", 0, "₲"),
+	active("QAR", "634", "Qatari Rial", 2, "ر.ق"),
+	historic("ROL", "642", "Old Leu", 2, "lei"),
+	active("RON", "946", "Romanian Leu", 2, "lei"),
+	active("RSD", "941", "Serbian Dinar", 2, "дин."),
+	active("RUB", "643", "Russian Ruble", 2, "₽"),
+	active("RWF", "646", "Rwanda Franc", 0, "FRw"),
+	active("SAR", "682", "Saudi Riyal", 2, "ر.س"),
+	active("SBD", "090", "Solomon Islands Dollar", 2, "$"),
+	active("SCR", "690", "Seychelles Rupee", 2, "₨"),
+	active("SDG", "938", "Sudanese Pound", 2, "ج.س."),
+	active("SEK", "752", "Swedish Krona", 2, "kr"),
+	active("SGD", "702", "Singapore Dollar", 2, "$"),
+	active("SHP", "654", "Saint Helena Pound", 2, "£"),
+	historic("SIT", "705", "Tolar", 2, "SIT"),
+	historic("SKK", "703", "Slovak Koruna", 2, "Sk"),
+	active("SLE", "925", "Leone", 2, "Le"),
+	historic("SLL", "694", "Leone", 2, "Le"),
+	active("SOS", "706", "Somali Shilling", 2, "Sh"),
+	active("SRD", "968", "Surinam Dollar", 2, "$"),
+	active("SSP", "728", "South Sudanese Pound", 2, "£"),
+	historic("STD", "678", "Dobra", 2, "Db"),
+	active("STN", "930", "Dobra", 2, "Db"),
+	active("SVC", "222", "El Salvador Colon", 2, "₡"),
+	active("SYP", "760", "Syrian Pound", 2, "£"),
+	active("SZL", "748", "Lilangeni", 2, "E"),
+	active("THB", "764", "Baht", 2, "฿"),
+	active("TJS", "972", "Somoni", 2, "SM"),
+	active("TMT", "934", "Turkmenistan New Manat", 2, "m"),
+	active("TND", "788", "Tunisian Dinar", 3, "د.ت"),
+	active("TOP", "776", "Pa'anga", 2, "T$"),
+	historic("TRL", "792", "Old Turkish Lira", 0, "₤"),
+	active("TRY", "949", "Turkish Lira", 2, "₺"),
+	active("TTD", "780", "Trinidad and Tobago Dollar", 2, "$"),
+	active("TWD", "901", "New Taiwan Dollar", 2, "NT$"),
+	active("TZS", "834", "Tanzanian Shilling", 2, "TSh"),
+	active("UAH", "980", "Hryvnia", 2, "₴"),
+	active("UGX", "800", "Uganda Shilling", 0, "USh"),
+	active("USD", "840", "US Dollar", 2, "$"),
+	active("USN", "997", "US Dollar (Next day)", 2, ""),
+	active("UYI", "940", "Uruguay Peso en Unidades Indexadas (UI)", 0, ""),
+	active("UYU", "858", "Peso Uruguayo", 2, "$"),
+	active("UYW", "927", "Unidad Previsional", 4, ""),
+	active("UZS", "860", "Uzbekistan Sum", 2, "soʻm"),
+	active("VED", "926", "Bolívar Soberano", 2, "Bs.D"),
+	historic("VEF", "937", "Bolívar", 2, "Bs.F"),
+	active("VES", "928", "Bolívar Soberano", 2, "Bs.S"),
+	active("VND", "704", "Dong", 0, "₫"),
+	active("VUV", "548", "Vatu", 0, "VT"),
+	active("WST", "882", "Tala", 2, "T"),
+	active("XAF", "950", "CFA Franc BEAC", 0, "FCFA"),
+	active("XCD", "951", "East Caribbean Dollar", 2, "$"),
+	active("XCG", "532", "Caribbean Guilder", 2, "Cg"),
+	active("XOF", "952", "CFA Franc BCEAO", 0, "CFA"),
+	active("XPF", "953", "CFP Franc", 0, "₣"),
+	active("YER", "886", "Yemeni Rial", 2, "﷼"),
+	active("ZAR", "710", "Rand", 2, "R"),
+	historic("ZMK", "894", "Zambian Kwacha", 2, "ZK"),
+	active("ZMW", "967", "Zambian Kwacha", 2, "ZK"),
+	active("ZWG", "924", "Zimbabwe Gold", 2, "ZiG"),
+	historic("ZWL", "932", "Zimbabwe Dollar", 2, "$"),
+}
+
+// Returns currency that is currently in use
+func active(code, numeric, name string, minorUnits int32, symbol string) Currency {
+	return Currency{Code: code, Numeric: numeric, Name: name, MinorUnits: minorUnits,
+		Symbol: symbol, Active: true}
+}
+
+// Returns historic currency that is no longer in use
+func historic(code, numeric, name string, minorUnits int32, symbol string) Currency {
+	return Currency{Code: code, Numeric: numeric, Name: name, MinorUnits: minorUnits,
+		Symbol: symbol}
+}
+
+// Registry index by alphabetic code
+var currencyIndex = indexCurrencies(currencies)
+
+func indexCurrencies(list []Currency) map[string]Currency {
+	index := make(map[string]Currency, len(list))
+	for _, currency := range list {
+		index[currency.Code] = currency
+	}
+
+	return index
+}
+
+// Currencies returns all currencies known to the registry sorted by their code
+func Currencies() []Currency {
+	result := make([]Currency, len(currencies))
+	copy(result, currencies)
+	sort.Sort(byCode(result))
+	return result
+}
+
+// LookupCurrency returns currency registered under given code. Code is case insensitive.
+func LookupCurrency(code string) (Currency, bool) {
+	currency, exists := currencyIndex[strings.ToUpper(strings.TrimSpace(code))]
+	return currency, exists
+}
+
+// NormalizeCurrency validates given currency code against the registry and returns it in its
+// canonical, upper case form.
+func NormalizeCurrency(code string) (string, error) {
+	currency, exists := LookupCurrency(code)
+	if !exists {
+		return "", NewError(ErrInvalidCurrency, "Currency %s is not a valid ISO 4217 currency code.",
+			code)
+	}
+
+	return currency.Code, nil
+}
+
+type byCode []Currency
+
+func (b byCode) Len() int           { return len(b) }
+func (b byCode) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byCode) Less(i, j int) bool { return b[i].Code < b[j].Code }
@@ -0,0 +1,86 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	cases := []struct {
+		code          string
+		expected      string
+		expectedError bool
+	}{
+		{"USD", "USD", false},
+		{"eur", "EUR", false},
+		{" pln ", "PLN", false},
+		{"DEM", "DEM", false},
+		{"USX", "", true},
+		{"", "", true},
+	}
+
+	for _, c := range cases {
+		actual, err := NormalizeCurrency(c.code)
+
+		if actual != c.expected || (err != nil) != c.expectedError {
+			t.Errorf("NormalizeCurrency(%s) == \ngot: %s, %v \nexpected %s", c.code, actual, err,
+				c.expected)
+		}
+	}
+}
+
+func TestLookupCurrency(t *testing.T) {
+	cases := []struct {
+		code     string
+		expected Currency
+		exists   bool
+	}{
+		{"KWD", Currency{Code: "KWD", Numeric: "414", Name: "Kuwaiti Dinar", MinorUnits: 3,
+			Symbol: "د.ك", Active: true}, true},
+		{"jpy", Currency{Code: "JPY", Numeric: "392", Name: "Yen", MinorUnits: 0, Symbol: "¥",
+			Active: true}, true},
+		{"FRF", Currency{Code: "FRF", Numeric: "250", Name: "French Franc", MinorUnits: 2,
+			Symbol: "F", Active: false}, true},
+		{"XXX", Currency{}, false},
+	}
+
+	for _, c := range cases {
+		actual, exists := LookupCurrency(c.code)
+
+		if actual != c.expected || exists != c.exists {
+			t.Errorf("LookupCurrency(%s) == \ngot: %v, %t \nexpected %v, %t", c.code, actual,
+				exists, c.expected, c.exists)
+		}
+	}
+}
+
+func TestCurrencies(t *testing.T) {
+	actual := Currencies()
+
+	if len(actual) != len(currencyIndex) {
+		t.Errorf("Currencies() returned %d currencies, expected %d unique codes", len(actual),
+			len(currencyIndex))
+	}
+
+	if !sort.IsSorted(byCode(actual)) {
+		t.Errorf("Currencies() == \ngot: unsorted list, \nexpected list sorted by code")
+	}
+
+	for _, currency := range actual {
+		if len(currency.Code) != 3 || len(currency.Numeric) != 3 {
+			t.Errorf("Currencies() returned malformed currency: %v", currency)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Biggest exponent accepted while parsing decimal values. Guards against inputs like '1e999999999'.
+const maxDecimalExponent = 400
+
+var bigTen = big.NewInt(10)
+
+// Decimal is an exact, arbitrary-precision decimal number used for money arithmetic. Its value is
+// equal to unscaled * 10^-scale. Zero value of Decimal is a valid 0. Decimals are immutable,
+// every operation returns a new value.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns decimal equal to unscaled * 10^-scale
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}.normalize()
+}
+
+// NewDecimalFromFloat returns decimal equal to the shortest decimal representation of given float
+func NewDecimalFromFloat(value float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+
+	return d
+}
+
+// ParseDecimal parses decimal string i.e. '12', '-0.055', '1.5e-3' without any loss of precision
+func ParseDecimal(value string) (Decimal, error) {
+	str := strings.TrimSpace(value)
+	exponent := int64(0)
+
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil || exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("Invalid decimal value: '%s'.", value)
+		}
+
+		exponent = exp
+		str = str[:i]
+	}
+
+	scale := int64(0)
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		scale = int64(len(str) - i - 1)
+		str = str[:i] + str[i+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("Invalid decimal value: '%s'.", value)
+	}
+
+	return Decimal{unscaled: unscaled, scale: int32(scale - exponent)}.normalize(), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if value can not be parsed. Meant to be used
+// only with constant values.
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// Makes sure that scale is never negative, so that every value can be printed without exponent
+func (d Decimal) normalize() Decimal {
+	if d.scale >= 0 {
+		return d
+	}
+
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), pow10(-d.scale)), scale: 0}
+}
+
+// Returns unscaled value of decimal. Nil unscaled value of zero Decimal is treated as 0.
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.unscaled
+}
+
+// Returns decimal with the same value and given scale. Scale has to be greater or equal to current.
+func (d Decimal) rescale(scale int32) Decimal {
+	if scale <= d.scale {
+		return d
+	}
+
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), pow10(scale-d.scale)), scale: scale}
+}
+
+// Scale returns number of digits after the decimal point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1 depending on decimal being negative, zero or positive
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero returns true if decimal is equal to 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := maxScale(d.scale, other.scale)
+	return Decimal{
+		unscaled: new(big.Int).Add(d.rescale(scale).int(), other.rescale(scale).int()),
+		scale:    scale,
+	}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	return d.Add(other.Neg())
+}
+
+// Mul returns d * other. Result is exact, its scale is a sum of both scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), other.int()), scale: d.scale + other.scale}
+}
+
+// Quo returns d / other rounded to given scale using given rounding mode. Other must not be zero.
+func (d Decimal) Quo(other Decimal, scale int32, mode RoundingMode) Decimal {
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Abs(other.int())
+	if shift := scale + other.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	if other.Sign() < 0 {
+		num.Neg(num)
+	}
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && roundsAwayFromZero(mode, num.Sign(), quo, rem, den) {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+
+	return Decimal{unscaled: quo, scale: scale}
+}
+
+// Cmp compares two decimals and returns -1 if d < other, 0 if d == other and 1 if d > other
+func (d Decimal) Cmp(other Decimal) int {
+	scale := maxScale(d.scale, other.scale)
+	return d.rescale(scale).int().Cmp(other.rescale(scale).int())
+}
+
+// Equal returns true if both decimals represent the same value regardless of their scale
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Round rounds decimal to given number of places after the decimal point. Rounding half away
+// from zero. Returned decimal always has exactly 'places' scale.
+func (d Decimal) Round(places int32) Decimal {
+	return d.RoundWithMode(places, HalfUp)
+}
+
+// RoundWithMode rounds decimal to given number of places after the decimal point using given
+// rounding mode. Unknown modes fall back to DefaultRoundingMode. Returned decimal always has
+// exactly 'places' scale.
+func (d Decimal) RoundWithMode(places int32, mode RoundingMode) Decimal {
+	if d.scale <= places {
+		return d.rescale(places)
+	}
+
+	divisor := pow10(d.scale - places)
+	quo, rem := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+
+	if rem.Sign() != 0 && roundsAwayFromZero(mode, d.Sign(), quo, rem, divisor) {
+		quo.Add(quo, big.NewInt(int64(d.Sign())))
+	}
+
+	return Decimal{unscaled: quo, scale: places}
+}
+
+// Decides whether value truncated to quo with remainder rem should be moved away from zero
+func roundsAwayFromZero(mode RoundingMode, sign int, quo, rem, divisor *big.Int) bool {
+	switch mode {
+	case Truncate:
+		return false
+	case Floor:
+		return sign < 0
+	case Ceiling:
+		return sign > 0
+	}
+
+	half := new(big.Int).Lsh(new(big.Int).Abs(rem), 1).Cmp(divisor)
+	if mode == HalfEven && half == 0 {
+		return new(big.Int).Abs(quo).Bit(0) == 1
+	}
+
+	return half >= 0
+}
+
+// Float64 returns the nearest float64 value. Should be used only for compatibility purposes as
+// precision might be lost.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns decimal in a plain notation keeping all digits of its scale, i.e. '123.40'
+func (d Decimal) String() string {
+	digits := d.int().String()
+	if d.scale <= 0 {
+		return digits
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	if missing := int(d.scale) - len(digits) + 1; missing > 0 {
+		digits = strings.Repeat("0", missing) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalText implements encoding.TextMarshaler. Used i.e. by XML encoder.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Decimal is marshalled as a string to keep exact value.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Accepts both JSON numbers and strings.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	return d.UnmarshalText(bytes.Trim(data, `"`))
+}
+
+// Returns 10^n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func maxScale(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,159 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := []struct {
+		value         string
+		expected      string
+		expectedError bool
+	}{
+		{"12", "12", false},
+		{"-0.055", "-0.055", false},
+		{"+.5", "0.5", false},
+		{"1.5e-3", "0.0015", false},
+		{"1.5E3", "1500", false},
+		{"0.00000000000000000001", "0.00000000000000000001", false},
+		{"", "0", true},
+		{"abc", "0", true},
+		{"1.2.3", "0", true},
+		{"1e999999", "0", true},
+		{"NaN", "0", true},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseDecimal(c.value)
+
+		if (err != nil) != c.expectedError || actual.String() != c.expected {
+			t.Errorf("ParseDecimal(%s) == \ngot: %s, %v \nexpected %s", c.value, actual, err,
+				c.expected)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	cases := []struct {
+		a, b        string
+		expectedAdd string
+		expectedSub string
+		expectedMul string
+		expectedCmp int
+	}{
+		{"0.1", "0.2", "0.3", "-0.1", "0.02", -1},
+		{"1.005", "1000", "1001.005", "-998.995", "1005.000", -1},
+		{"-2.5", "-2.50", "-5.00", "0.00", "6.250", 0},
+		{"3", "0", "3", "3", "0", 1},
+	}
+
+	for _, c := range cases {
+		a, b := MustParseDecimal(c.a), MustParseDecimal(c.b)
+
+		if actual := a.Add(b).String(); actual != c.expectedAdd {
+			t.Errorf("%s.Add(%s) == \ngot: %s, \nexpected %s", c.a, c.b, actual, c.expectedAdd)
+		}
+
+		if actual := a.Sub(b).String(); actual != c.expectedSub {
+			t.Errorf("%s.Sub(%s) == \ngot: %s, \nexpected %s", c.a, c.b, actual, c.expectedSub)
+		}
+
+		if actual := a.Mul(b).String(); actual != c.expectedMul {
+			t.Errorf("%s.Mul(%s) == \ngot: %s, \nexpected %s", c.a, c.b, actual, c.expectedMul)
+		}
+
+		if actual := a.Cmp(b); actual != c.expectedCmp {
+			t.Errorf("%s.Cmp(%s) == \ngot: %d, \nexpected %d", c.a, c.b, actual, c.expectedCmp)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	cases := []struct {
+		value    string
+		places   int32
+		expected string
+	}{
+		{"1.23456", 0, "1"},
+		{"1.23456", 3, "1.235"},
+		{"0.055", 2, "0.06"},
+		{"-0.055", 2, "-0.06"},
+		{"-1.234", 2, "-1.23"},
+		{"5", 2, "5.00"},
+		{"0.004", 2, "0.00"},
+	}
+
+	for _, c := range cases {
+		actual := MustParseDecimal(c.value).Round(c.places)
+
+		if actual.String() != c.expected {
+			t.Errorf("%s.Round(%d) == \ngot: %s, \nexpected %s", c.value, c.places, actual,
+				c.expected)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected string
+	}{
+		{`1.01`, `"1.01"`},
+		{`"1.01"`, `"1.01"`},
+		{`0.00055`, `"0.00055"`},
+		{`13040.0`, `"13040.0"`},
+	}
+
+	for _, c := range cases {
+		var d Decimal
+		if err := json.Unmarshal([]byte(c.data), &d); err != nil {
+			t.Errorf("json.Unmarshal(%s) returned error: %v", c.data, err)
+			continue
+		}
+
+		actual, err := json.Marshal(d)
+		if err != nil || string(actual) != c.expected {
+			t.Errorf("json.Marshal(%s) == \ngot: %s, \nexpected %s", c.data, actual, c.expected)
+		}
+	}
+}
+
+func TestDecimalQuo(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		scale    int32
+		mode     RoundingMode
+		expected string
+	}{
+		{"1", "3", 4, HalfUp, "0.3333"},
+		{"2", "3", 4, HalfUp, "0.6667"},
+		{"2", "3", 4, Truncate, "0.6666"},
+		{"-2", "3", 2, HalfUp, "-0.67"},
+		{"2", "-3", 2, Floor, "-0.67"},
+		{"9.865", "4.3278", 6, HalfUp, "2.279449"},
+		{"0.125", "0.5", 1, HalfEven, "0.2"},
+		{"1500", "0.001", 0, HalfUp, "1500000"},
+	}
+
+	for _, c := range cases {
+		actual := MustParseDecimal(c.a).Quo(MustParseDecimal(c.b), c.scale, c.mode)
+
+		if actual.String() != c.expected {
+			t.Errorf("%s.Quo(%s, %d, %s) == \ngot: %s, \nexpected %s", c.a, c.b, c.scale, c.mode,
+				actual, c.expected)
+		}
+	}
+}
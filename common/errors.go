@@ -0,0 +1,164 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrorCode identifies kind of an error returned to clients
+type ErrorCode string
+
+// Error codes returned to clients
+const (
+	// ErrInvalidAmount - amount is not a valid non-negative decimal number
+	ErrInvalidAmount ErrorCode = "INVALID_AMOUNT"
+	// ErrInvalidCurrency - currency is not a valid ISO 4217 code
+	ErrInvalidCurrency ErrorCode = "INVALID_CURRENCY"
+	// ErrInvalidParameter - any other request parameter is invalid
+	ErrInvalidParameter ErrorCode = "INVALID_PARAMETER"
+	// ErrUnsupportedCurrency - currency is valid, but provider does not serve its rates
+	ErrUnsupportedCurrency ErrorCode = "UNSUPPORTED_CURRENCY"
+	// ErrRateNotFound - provider has no rate for requested currencies or date
+	ErrRateNotFound ErrorCode = "RATE_NOT_FOUND"
+	// ErrNotFound - requested resource does not exist
+	ErrNotFound ErrorCode = "NOT_FOUND"
+	// ErrUnauthorized - request does not carry valid credentials
+	ErrUnauthorized ErrorCode = "UNAUTHORIZED"
+	// ErrUpstreamUnavailable - upstream service failed or returned invalid response
+	ErrUpstreamUnavailable ErrorCode = "UPSTREAM_UNAVAILABLE"
+	// ErrProviderUnavailable - provider is temporarily not asked for rates
+	ErrProviderUnavailable ErrorCode = "PROVIDER_UNAVAILABLE"
+	// ErrUpstreamTimeout - upstream service did not respond in time
+	ErrUpstreamTimeout ErrorCode = "UPSTREAM_TIMEOUT"
+	// ErrInternal - any other error
+	ErrInternal ErrorCode = "INTERNAL_ERROR"
+)
+
+// HTTP statuses of error codes
+var errorStatuses = map[ErrorCode]int{
+	ErrInvalidAmount:       http.StatusBadRequest,
+	ErrInvalidCurrency:     http.StatusBadRequest,
+	ErrInvalidParameter:    http.StatusBadRequest,
+	ErrUnsupportedCurrency: http.StatusUnprocessableEntity,
+	ErrRateNotFound:        http.StatusNotFound,
+	ErrNotFound:            http.StatusNotFound,
+	ErrUnauthorized:        http.StatusUnauthorized,
+	ErrUpstreamUnavailable: http.StatusBadGateway,
+	ErrProviderUnavailable: http.StatusServiceUnavailable,
+	ErrUpstreamTimeout:     http.StatusGatewayTimeout,
+	ErrInternal:            http.StatusInternalServerError,
+}
+
+// Messages returned to clients in place of errors that did not originate in this application.
+// Their texts may reveal upstream urls, credentials or internals and are only logged.
+var errorMessages = map[ErrorCode]string{
+	ErrUpstreamUnavailable: "Upstream provider unavailable.",
+	ErrUpstreamTimeout:     "Upstream provider did not respond in time.",
+	ErrInternal:            "Internal error.",
+}
+
+// Status returns HTTP status of responses with given error code
+func (e ErrorCode) Status() int {
+	if status, exists := errorStatuses[e]; exists {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Error is an error returned to clients. It is rendered as a response body.
+type Error struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"error"`
+
+	// Code identifying kind of the error
+	Code ErrorCode `json:"code" xml:"code"`
+
+	// Message describing the error
+	Message string `json:"message" xml:"message"`
+
+	// Parameter - name of the request parameter that caused the error
+	Parameter string `json:"parameter,omitempty" xml:"parameter,omitempty"`
+}
+
+// Error implements error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Status returns HTTP status of the error
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// WithParameter returns copy of the error caused by request parameter with given name. Parameter
+// that is already set is kept.
+func (e *Error) WithParameter(name string) *Error {
+	result := *e
+	if len(result.Parameter) == 0 {
+		result.Parameter = name
+	}
+
+	return &result
+}
+
+// Describer is implemented by errors that know how they should be returned to clients
+type Describer interface {
+	// Describe returns error returned to clients
+	Describe() *Error
+}
+
+// NewError returns error with given code and formatted message
+func NewError(code ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsError converts any error to an error returned to clients. Upstream HTTP and network errors
+// are reported as ErrUpstreamUnavailable, timeouts as ErrUpstreamTimeout and unknown errors as
+// ErrInternal. Messages of errors other than Error and Describer are replaced by a generic message
+// of their code, as they may reveal upstream urls or internals.
+func AsError(err error) *Error {
+	switch e := err.(type) {
+	case *Error:
+		return e
+	case Describer:
+		return e.Describe()
+	}
+
+	if timeout, ok := err.(interface {
+		Timeout() bool
+	}); ok && timeout.Timeout() {
+		return genericError(ErrUpstreamTimeout)
+	}
+
+	switch err.(type) {
+	case *HTTPError, net.Error:
+		return genericError(ErrUpstreamUnavailable)
+	}
+
+	if err == context.DeadlineExceeded {
+		return genericError(ErrUpstreamTimeout)
+	}
+
+	return genericError(ErrInternal)
+}
+
+// Returns error with given code and its generic message
+func genericError(code ErrorCode) *Error {
+	return &Error{Code: code, Message: errorMessages[code]}
+}
@@ -0,0 +1,89 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// Error describing itself as an error returned to clients
+type describedError struct{}
+
+func (describedError) Error() string {
+	return "Described."
+}
+
+func (describedError) Describe() *Error {
+	return NewError(ErrProviderUnavailable, "Described.")
+}
+
+func TestAsError(t *testing.T) {
+	cases := []struct {
+		err             error
+		expectedCode    ErrorCode
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{NewError(ErrInvalidAmount, "Invalid."), ErrInvalidAmount, http.StatusBadRequest,
+			"Invalid."},
+		{NewError(ErrUnsupportedCurrency, "Unsupported."), ErrUnsupportedCurrency,
+			http.StatusUnprocessableEntity, "Unsupported."},
+		{NewError(ErrRateNotFound, "Not found."), ErrRateNotFound, http.StatusNotFound,
+			"Not found."},
+		{describedError{}, ErrProviderUnavailable, http.StatusServiceUnavailable, "Described."},
+		{&HTTPError{URL: "http://localhost", StatusCode: http.StatusInternalServerError},
+			ErrUpstreamUnavailable, http.StatusBadGateway, "Upstream provider unavailable."},
+		{context.DeadlineExceeded, ErrUpstreamTimeout, http.StatusGatewayTimeout,
+			"Upstream provider did not respond in time."},
+		{errors.New("Unknown at /etc/secret."), ErrInternal, http.StatusInternalServerError,
+			"Internal error."},
+	}
+
+	for _, c := range cases {
+		got := AsError(c.err)
+		if got.Code != c.expectedCode || got.Status() != c.expectedStatus ||
+			got.Message != c.expectedMessage {
+			t.Errorf("AsError(%v) == \ngot: %s (%d) %s, \nexpected %s (%d) %s", c.err, got.Code,
+				got.Status(), got.Message, c.expectedCode, c.expectedStatus, c.expectedMessage)
+		}
+	}
+}
+
+func TestErrorMarshal(t *testing.T) {
+	err := NewError(ErrInvalidCurrency, "Currency %s is invalid.", "ABC").WithParameter("from")
+	cases := []struct {
+		marshal  func(interface{}) ([]byte, error)
+		expected string
+	}{
+		{json.Marshal, `{"code":"INVALID_CURRENCY","message":"Currency ABC is invalid.",` +
+			`"parameter":"from"}`},
+		{xml.Marshal, "<error><code>INVALID_CURRENCY</code><message>Currency ABC is invalid." +
+			"</message><parameter>from</parameter></error>"},
+	}
+
+	for _, c := range cases {
+		got, e := c.marshal(err)
+		if e != nil || string(got) != c.expected {
+			t.Errorf("Marshal(%v) == \ngot: %s, %v \nexpected %s", err, got, e, c.expected)
+		}
+	}
+
+	if kept := err.WithParameter("to"); kept.Parameter != "from" {
+		t.Errorf("WithParameter(to) == \ngot: %s, \nexpected from", kept.Parameter)
+	}
+}
@@ -13,18 +13,271 @@
 package common
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// GetJson - makes HTTP get request to provided url and returns decoded target interface object
-func GetJson(url string, target interface{}) error {
-	r, err := http.Get(url)
+// Upper bound of any upstream request. Requests are usually limited earlier by their context.
+const maxRequestTimeout = time.Minute
+
+// Number of bytes of upstream response body kept in HTTPError
+const errorBodySnippetSize = 512
+
+// UserAgent is sent with every upstream request unless overridden by client headers
+const UserAgent = "go-currency"
+
+// Transport shared by all clients, so that connections to upstream hosts are pooled
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// DefaultRetryPolicy retries failed requests twice, waiting 200ms and 400ms on average
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 200 * time.Millisecond,
+	MaxDelay: 5 * time.Second}
+
+// DefaultHTTPClient is used by GetJson and GetXml
+var DefaultHTTPClient = NewHTTPClient(nil, DefaultRetryPolicy)
+
+// HTTPError is returned when upstream responds with a status other than 2xx
+type HTTPError struct {
+	// URL - requested url without query and credentials, see RedactURL
+	URL string
+	// StatusCode - status of upstream response
+	StatusCode int
+	// Body - beginning of upstream response body
+	Body string
+	// RetryAfter - how long upstream asked to wait before the next request, 0 if not given
+	RetryAfter time.Duration
+}
+
+// Error implements error interface
+func (h *HTTPError) Error() string {
+	return fmt.Sprintf("Upstream %s responded with %d %s: %s", h.URL, h.StatusCode,
+		http.StatusText(h.StatusCode), h.Body)
+}
+
+// Temporary returns true if request may succeed when retried, that is for 429 Too Many Requests
+// and 5xx statuses
+func (h *HTTPError) Temporary() bool {
+	return h.StatusCode == http.StatusTooManyRequests || h.StatusCode >= 500
+}
+
+// RetryPolicy describes how failed upstream requests are retried. Requests are retried after
+// network errors and temporary HTTP errors only, with exponential backoff and jitter.
+type RetryPolicy struct {
+	// MaxAttempts - how many times request is made at most, no retries if lower than 2
+	MaxAttempts int
+	// BaseDelay - average delay before the first retry, doubled before every next one
+	BaseDelay time.Duration
+	// MaxDelay - longest delay before a retry. Requests whose Retry-After asks to wait longer are
+	// not retried.
+	MaxDelay time.Duration
+}
+
+// Returns delay before given retry (counted from 1). Jitter spreads delays evenly between half
+// and full exponential delay.
+func (r RetryPolicy) backoff(retry int, random func() float64) time.Duration {
+	delay := r.BaseDelay << uint(retry-1)
+	if delay > r.MaxDelay || delay <= 0 {
+		delay = r.MaxDelay
+	}
+
+	return delay/2 + time.Duration(random()*float64(delay/2))
+}
+
+// HTTPClient makes upstream requests. It validates response status, retries failed requests and
+// sends custom headers with every request.
+type HTTPClient struct {
+	client  *http.Client
+	headers http.Header
+	retry   RetryPolicy
+
+	random func() float64
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// GetJson makes HTTP get request to given url and decodes JSON response into target. Request is
+// abandoned once given context is done.
+func (h HTTPClient) GetJson(ctx context.Context, url string, target interface{}) error {
+	return h.get(ctx, url, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(target)
+	})
+}
+
+// GetXml makes HTTP get request to given url and decodes XML response into target. Request is
+// abandoned once given context is done.
+func (h HTTPClient) GetXml(ctx context.Context, url string, target interface{}) error {
+	return h.get(ctx, url, func(body io.Reader) error {
+		return xml.NewDecoder(body).Decode(target)
+	})
+}
+
+// Makes request, retrying it if needed, and passes body of successful response to decode
+func (h HTTPClient) get(ctx context.Context, url string, decode func(io.Reader) error) error {
+	for attempt := 1; ; attempt++ {
+		err := h.do(ctx, url, decode)
+		if err == nil || attempt >= h.retry.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		delay, retryable := h.retryDelay(err, attempt)
+		if !retryable {
+			return err
+		}
+
+		log.Printf("Request to %s failed, retrying in %s: %s", RedactURL(url), delay, err)
+		if sleepErr := h.sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// Makes a single request
+func (h HTTPClient) do(ctx context.Context, url string, decode func(io.Reader) error) error {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
 
-	defer r.Body.Close()
+	request.Header.Set("User-Agent", UserAgent)
+	for name, values := range h.headers {
+		request.Header[name] = values
+	}
 
-	return json.NewDecoder(r.Body).Decode(target)
+	response, err := h.client.Do(request.WithContext(ctx))
+	if urlErr, ok := err.(*neturl.Error); ok {
+		urlErr.URL = RedactURL(url)
+		return urlErr
+	}
+
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		snippet, _ := ioutil.ReadAll(io.LimitReader(response.Body, errorBodySnippetSize))
+		return &HTTPError{URL: RedactURL(url), StatusCode: response.StatusCode,
+			Body:       strings.TrimSpace(string(snippet)),
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now())}
+	}
+
+	if err := decode(response.Body); err != nil {
+		log.Printf("Invalid response from %s: %s", RedactURL(url), err)
+		return NewError(ErrUpstreamUnavailable, "Invalid response from upstream provider.")
+	}
+
+	return nil
+}
+
+// Decides whether request should be retried after given error and how long to wait before
+func (h HTTPClient) retryDelay(err error, attempt int) (time.Duration, bool) {
+	if httpErr, ok := err.(*HTTPError); ok {
+		if !httpErr.Temporary() || httpErr.RetryAfter > h.retry.MaxDelay {
+			return 0, false
+		}
+
+		if httpErr.RetryAfter > 0 {
+			return httpErr.RetryAfter, true
+		}
+	} else if _, ok := err.(net.Error); !ok {
+		// Neither network nor HTTP error, i.e. invalid response body
+		return 0, false
+	}
+
+	return h.retry.backoff(attempt, h.random), true
+}
+
+// RedactURL returns given url without query and user credentials, which may carry secrets such as
+// API keys, so that it can be logged or embedded in errors
+func RedactURL(url string) string {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return "upstream"
+	}
+
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+// Parses Retry-After header given either in seconds or as a HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// Waits for given duration or until context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetJson - makes HTTP get request to provided url and returns decoded target interface object.
+// Request is abandoned once given context is done.
+func GetJson(ctx context.Context, url string, target interface{}) error {
+	return DefaultHTTPClient.GetJson(ctx, url, target)
+}
+
+// GetXml - makes HTTP get request to provided url and returns decoded target interface object.
+// Request is abandoned once given context is done.
+func GetXml(ctx context.Context, url string, target interface{}) error {
+	return DefaultHTTPClient.GetXml(ctx, url, target)
+}
+
+// NewHTTPClient returns client sending given headers with every request and retrying failed
+// requests according to given policy. Connections are pooled across all clients.
+func NewHTTPClient(headers map[string]string, retry RetryPolicy) HTTPClient {
+	header := make(http.Header, len(headers))
+	for name, value := range headers {
+		header.Set(name, value)
+	}
+
+	return HTTPClient{
+		client:  &http.Client{Transport: sharedTransport, Timeout: maxRequestTimeout},
+		headers: header,
+		retry:   retry,
+		random:  rand.Float64,
+		sleep:   sleepContext,
+	}
 }
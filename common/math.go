@@ -14,19 +14,20 @@ package common
 
 import "math"
 
-// Round is used to round floating point numbers with given precision. Rounding up from '.5'
+// Round is used to round floating point numbers with given precision. Rounding half away from
+// zero. Exact money calculations should use Decimal instead.
 func Round(val float64, places int) (newVal float64) {
 	var round float64
 	roundOn := .5
 
 	pow := math.Pow(10, float64(places))
-	digit := pow * val
+	digit := math.Abs(pow * val)
 	_, div := math.Modf(digit)
 	if div >= roundOn {
 		round = math.Ceil(digit)
 	} else {
 		round = math.Floor(digit)
 	}
-	newVal = round / pow
+	newVal = math.Copysign(round/pow, val)
 	return
 }
@@ -33,6 +33,9 @@ func TestRound(t *testing.T) {
 		{1.23456, 1, 1.2},
 		{1.23456, 2, 1.23},
 		{1.23456, 3, 1.235},
+		{-1.234, 2, -1.23},
+		{-1.235, 2, -1.24},
+		{-0.4, 0, 0},
 	}
 
 	for _, c := range cases {
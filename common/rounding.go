@@ -0,0 +1,77 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+)
+
+// RoundingMode describes how values are rounded to the minor unit of a currency
+type RoundingMode string
+
+// Supported rounding modes
+const (
+	// HalfUp rounds towards the nearest neighbour, ties are rounded away from zero
+	HalfUp RoundingMode = "half-up"
+	// HalfEven rounds towards the nearest neighbour, ties are rounded to the even neighbour
+	HalfEven RoundingMode = "half-even"
+	// Floor rounds towards negative infinity
+	Floor RoundingMode = "floor"
+	// Ceiling rounds towards positive infinity
+	Ceiling RoundingMode = "ceiling"
+	// Truncate rounds towards zero
+	Truncate RoundingMode = "truncate"
+)
+
+// DefaultRoundingMode is used whenever rounding mode is not specified
+const DefaultRoundingMode = HalfUp
+
+// Minor unit used for currencies that are not known to the registry
+const defaultMinorUnits = 2
+
+// Currencies that are in practice settled with different number of decimal places than their
+// ISO 4217 minor unit.
+var settlementMinorUnits = map[string]int32{
+	"IDR": 0,
+}
+
+// ParseRoundingMode returns rounding mode matching given name or error if it is not supported
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	mode := RoundingMode(strings.ToLower(strings.TrimSpace(name)))
+	switch mode {
+	case HalfUp, HalfEven, Floor, Ceiling, Truncate:
+		return mode, nil
+	}
+
+	return "", NewError(ErrInvalidParameter,
+		"Rounding mode %s is not supported. Supported modes: %s, %s, %s, %s, %s.", name, HalfUp,
+		HalfEven, Floor, Ceiling, Truncate)
+}
+
+// MinorUnits returns number of decimal places used by given currency
+func MinorUnits(currency string) int32 {
+	if units, exists := settlementMinorUnits[strings.ToUpper(currency)]; exists {
+		return units
+	}
+
+	if currency, exists := LookupCurrency(currency); exists {
+		return currency.MinorUnits
+	}
+
+	return defaultMinorUnits
+}
+
+// RoundMoney rounds value to the minor unit of given currency using given rounding mode
+func RoundMoney(value Decimal, currency string, mode RoundingMode) Decimal {
+	return value.RoundWithMode(MinorUnits(currency), mode)
+}
@@ -0,0 +1,96 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import "testing"
+
+func TestRoundWithMode(t *testing.T) {
+	cases := []struct {
+		value    string
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.345", HalfUp, "2.35"},
+		{"-2.345", HalfUp, "-2.35"},
+		{"2.345", HalfEven, "2.34"},
+		{"2.355", HalfEven, "2.36"},
+		{"-2.345", HalfEven, "-2.34"},
+		{"2.3451", HalfEven, "2.35"},
+		{"2.341", Floor, "2.34"},
+		{"-2.341", Floor, "-2.35"},
+		{"2.341", Ceiling, "2.35"},
+		{"-2.341", Ceiling, "-2.34"},
+		{"2.349", Truncate, "2.34"},
+		{"-2.349", Truncate, "-2.34"},
+		{"2.3", Truncate, "2.30"},
+	}
+
+	for _, c := range cases {
+		actual := MustParseDecimal(c.value).RoundWithMode(2, c.mode)
+
+		if actual.String() != c.expected {
+			t.Errorf("%s.RoundWithMode(2, %s) == \ngot: %s, \nexpected %s", c.value, c.mode,
+				actual, c.expected)
+		}
+	}
+}
+
+func TestRoundMoney(t *testing.T) {
+	cases := []struct {
+		value    string
+		currency string
+		mode     RoundingMode
+		expected string
+	}{
+		{"123.456", "USD", HalfUp, "123.46"},
+		{"123.456", "jpy", HalfUp, "123"},
+		{"123.5", "KRW", HalfEven, "124"},
+		{"32982.4", "IDR", HalfUp, "32982"},
+		{"1.23456", "BHD", Truncate, "1.234"},
+		{"1.23451", "KWD", Ceiling, "1.235"},
+	}
+
+	for _, c := range cases {
+		actual := RoundMoney(MustParseDecimal(c.value), c.currency, c.mode)
+
+		if actual.String() != c.expected {
+			t.Errorf("RoundMoney(%s, %s, %s) == \ngot: %s, \nexpected %s", c.value, c.currency,
+				c.mode, actual, c.expected)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	cases := []struct {
+		name          string
+		expected      RoundingMode
+		expectedError bool
+	}{
+		{"half-up", HalfUp, false},
+		{"HALF-EVEN", HalfEven, false},
+		{" floor ", Floor, false},
+		{"ceiling", Ceiling, false},
+		{"truncate", Truncate, false},
+		{"bankers", "", true},
+		{"", "", true},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseRoundingMode(c.name)
+
+		if actual != c.expected || (err != nil) != c.expectedError {
+			t.Errorf("ParseRoundingMode(%s) == \ngot: %s, %v \nexpected %s", c.name, actual, err,
+				c.expected)
+		}
+	}
+}
@@ -0,0 +1,621 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+	"github.com/floreks/go-currency/provider/converter"
+)
+
+// Duration is a time.Duration read from a string like '5s' or '1m30s'
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("Invalid duration %s. Expected a string i.e. '5s'.", data)
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("Invalid duration '%s'. Expected i.e. '5s'.", value)
+	}
+
+	d.Duration = duration
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// ProviderConfig declares a single exchange rates provider
+type ProviderConfig struct {
+	// Name under which provider is served, defaults to its type
+	Name string `json:"name"`
+	// Type of the provider: fixerio, ecb, local, csv or aggregate
+	Type string `json:"type"`
+	// Enabled - whether provider is served, defaults to true
+	Enabled *bool `json:"enabled"`
+	// Endpoint - url of provider api (fixerio) or daily rates feed (ecb)
+	Endpoint string `json:"endpoint"`
+	// HistoryEndpoint - url of historical rates feed (ecb)
+	HistoryEndpoint string `json:"historyEndpoint"`
+	// APIKey sent with every request to provider api (fixerio)
+	APIKey string `json:"apiKey"`
+	// Headers sent with every request to provider api or feeds (fixerio, ecb)
+	Headers map[string]string `json:"headers"`
+	// Timeout - how long to wait for the provider before trying the next one
+	Timeout Duration `json:"timeout"`
+	// Pivot - currency through which cross rates are derived (local)
+	Pivot string `json:"pivot"`
+	// Snapshots - directory of exchange rates snapshot files served on top of built-in ones (local)
+	Snapshots string `json:"snapshots"`
+	// ReloadInterval - how often snapshot directory is checked for changes (local)
+	ReloadInterval Duration `json:"reloadInterval"`
+	// Files - rate files served by the provider (csv)
+	Files []string `json:"files"`
+	// Columns - header names of rate file columns, defaults are used for those not given (csv)
+	Columns *converter.CSVColumns `json:"columns"`
+	// Sources - names of providers whose rates are combined (aggregate)
+	Sources []string `json:"sources"`
+	// Aggregation - how rates of sources are combined (aggregate)
+	Aggregation converter.AggregationSettings `json:"aggregation"`
+	// Breaker - circuit breaker settings, defaults are used if not given
+	Breaker *BreakerConfig `json:"breaker"`
+	// Refresh - background refresh of latest rates, rates are fetched on request if not given
+	Refresh *RefreshConfig `json:"refresh"`
+}
+
+// BreakerConfig configures circuit breaker guarding a provider
+type BreakerConfig struct {
+	// FailureThreshold - consecutive failures after which circuit opens, breaker is disabled if 0
+	FailureThreshold int `json:"failureThreshold"`
+	// CoolDown - how long circuit stays open, defaults to 30s
+	CoolDown Duration `json:"coolDown"`
+}
+
+// IsEnabled returns true unless provider was explicitly disabled
+func (p ProviderConfig) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// GetName returns name under which provider is served
+func (p ProviderConfig) GetName() string {
+	if len(p.Name) == 0 {
+		return p.Type
+	}
+
+	return p.Name
+}
+
+// IsRemote returns true for providers fetching rates from an upstream api
+func (p ProviderConfig) IsRemote() bool {
+	return p.Type == converter.FixerIO || p.Type == converter.ECB
+}
+
+// IsCacheable returns false for providers serving rates from local files. Reading them is cheap
+// and caching would hide snapshots reloaded from disk until cache expires.
+func (p ProviderConfig) IsCacheable() bool {
+	return p.Type != converter.Local && p.Type != converter.CSV
+}
+
+// RefreshConfig describes background refresh of latest rates of a provider
+type RefreshConfig struct {
+	// Interval between refreshes
+	Interval Duration `json:"interval"`
+	// Times - daily times of refreshes in HH:MM format (UTC)
+	Times []string `json:"times"`
+	// Bases - currencies whose rates are refreshed, all bases of the provider if empty. Required
+	// for remote providers, as every base costs an upstream request on every refresh.
+	Bases []string `json:"bases"`
+}
+
+// Schedule returns schedule of refreshes
+func (r RefreshConfig) Schedule() converter.Schedule {
+	return converter.Schedule{Interval: r.Interval.Duration, Times: r.Times}
+}
+
+// BreakerSettings returns settings of circuit breaker guarding the provider
+func (p ProviderConfig) BreakerSettings() converter.BreakerSettings {
+	settings := converter.DefaultBreakerSettings
+	if p.Breaker != nil {
+		settings.FailureThreshold = p.Breaker.FailureThreshold
+		if p.Breaker.CoolDown.Duration > 0 {
+			settings.CoolDown = p.Breaker.CoolDown.Duration
+		}
+	}
+
+	return settings
+}
+
+// Config declares exchange rates providers served by the application
+type Config struct {
+	// DefaultProvider - name of the provider used when request does not select one
+	DefaultProvider string `json:"defaultProvider"`
+	// Providers - providers in the order they are tried by fallback provider
+	Providers []ProviderConfig `json:"providers"`
+	// Pricing - markups and fee profiles applied to conversions
+	Pricing converter.PricingConfig `json:"pricing"`
+	// AdminToken - bearer token required by admin API
+	AdminToken string `json:"adminToken"`
+	// Tenants - API keys by tenant name. Requests carrying a key are made on behalf of its tenant.
+	Tenants map[string]string `json:"tenants"`
+}
+
+// TenantKeys returns tenant names by their API keys
+func (c Config) TenantKeys() map[string]string {
+	tenants := make(map[string]string, len(c.Tenants))
+	for tenant, key := range c.Tenants {
+		tenants[key] = tenant
+	}
+
+	return tenants
+}
+
+// CSVColumns returns column mapping of rate files with defaults in place of not given columns
+func (p ProviderConfig) CSVColumns() converter.CSVColumns {
+	columns := converter.DefaultCSVColumns
+	if p.Columns == nil {
+		return columns
+	}
+
+	for _, column := range []struct{ value, target *string }{
+		{&p.Columns.Date, &columns.Date}, {&p.Columns.Base, &columns.Base},
+		{&p.Columns.Quote, &columns.Quote}, {&p.Columns.Rate, &columns.Rate},
+		{&p.Columns.Inverse, &columns.Inverse}, {&p.Columns.Delimiter, &columns.Delimiter},
+	} {
+		if len(*column.value) > 0 {
+			*column.target = *column.value
+		}
+	}
+
+	return columns
+}
+
+// Provider types that can be declared in configuration
+var providerTypes = []string{converter.FixerIO, converter.ECB, converter.Local, converter.CSV,
+	converter.Aggregate}
+
+// Provider names that are taken by providers registered by the application itself
+var reservedNames = []string{converter.Fallback, converter.Store}
+
+// Default returns configuration of all built-in providers with default endpoints. Fallback
+// provider is the default one.
+func Default() *Config {
+	return &Config{
+		DefaultProvider: converter.Fallback,
+		Providers: []ProviderConfig{
+			{Type: converter.FixerIO},
+			{Type: converter.ECB},
+			{Type: converter.Local},
+		},
+	}
+}
+
+// Load reads configuration from JSON file under given path and validates it
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := new(Config)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("Invalid configuration file %s: %s", path, err)
+	}
+
+	if len(config.DefaultProvider) == 0 {
+		config.DefaultProvider = converter.Fallback
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid configuration file %s: %s", path, err)
+	}
+
+	return config, nil
+}
+
+// Validate checks that configuration declares at least one provider, that all providers have a
+// known type, unique names and valid settings, and that the default provider is either enabled or
+// registered by the application itself. All found problems are reported in returned error.
+func (c Config) Validate() error {
+	problems := make([]string, 0)
+	names := make(map[string]bool)
+	enabled := 0
+
+	for i, provider := range c.Providers {
+		name := provider.GetName()
+		prefix := fmt.Sprintf("providers[%d] (%s)", i, name)
+
+		if !contains(providerTypes, provider.Type) {
+			problems = append(problems, fmt.Sprintf("%s: unknown type '%s', expected one of: %s",
+				prefix, provider.Type, strings.Join(providerTypes, ", ")))
+		}
+
+		if contains(reservedNames, name) {
+			problems = append(problems, fmt.Sprintf("%s: name '%s' is reserved", prefix, name))
+		}
+
+		if names[name] {
+			problems = append(problems, fmt.Sprintf("%s: duplicated name '%s'", prefix, name))
+		}
+
+		for _, endpoint := range []string{provider.Endpoint, provider.HistoryEndpoint} {
+			if err := validateEndpoint(endpoint); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", prefix, err))
+			}
+		}
+
+		if provider.Timeout.Duration < 0 {
+			problems = append(problems, fmt.Sprintf("%s: timeout can not be negative", prefix))
+		}
+
+		if provider.Breaker != nil && (provider.Breaker.FailureThreshold < 0 ||
+			provider.Breaker.CoolDown.Duration < 0) {
+			problems = append(problems, fmt.Sprintf("%s: breaker failure threshold and "+
+				"cool-down can not be negative", prefix))
+		}
+
+		if len(provider.Snapshots) > 0 {
+			if info, err := os.Stat(provider.Snapshots); err != nil || !info.IsDir() {
+				problems = append(problems, fmt.Sprintf("%s: snapshots '%s' is not a directory",
+					prefix, provider.Snapshots))
+			}
+		}
+
+		if provider.ReloadInterval.Duration < 0 {
+			problems = append(problems, fmt.Sprintf("%s: reload interval can not be negative",
+				prefix))
+		}
+
+		// Contents of rate files are checked once they are loaded by NewProvider
+		if provider.Type == converter.CSV {
+			problems = append(problems, validateFiles(prefix, provider.Files)...)
+		}
+
+		if provider.Type == converter.Aggregate {
+			problems = append(problems, c.validateAggregation(prefix, provider)...)
+		}
+
+		if provider.Refresh != nil {
+			problems = append(problems, validateRefresh(prefix, provider)...)
+		}
+
+		if len(provider.Pivot) > 0 {
+			problems = append(problems, validatePivot(prefix, provider)...)
+		}
+
+		names[name] = true
+		if provider.IsEnabled() {
+			enabled++
+		}
+	}
+
+	if enabled == 0 {
+		problems = append(problems, "at least one provider has to be enabled")
+	}
+
+	if !contains(reservedNames, c.DefaultProvider) && !c.isEnabled(c.DefaultProvider) {
+		problems = append(problems, fmt.Sprintf("default provider '%s' is not an enabled provider",
+			c.DefaultProvider))
+	}
+
+	problems = append(problems, validatePricing(c.Pricing)...)
+
+	keys := make(map[string]bool)
+	for tenant, key := range c.Tenants {
+		if len(key) == 0 || keys[key] {
+			problems = append(problems, fmt.Sprintf("tenants.%s: API key has to be non-empty and "+
+				"unique", tenant))
+		}
+
+		keys[key] = true
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Returns true if provider with given name is declared and enabled
+func (c Config) isEnabled(name string) bool {
+	for _, provider := range c.Providers {
+		if provider.GetName() == name && provider.IsEnabled() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnabledProviders returns configurations of all enabled providers in declared order
+func (c Config) EnabledProviders() []ProviderConfig {
+	providers := make([]ProviderConfig, 0, len(c.Providers))
+	for _, provider := range c.Providers {
+		if !provider.IsEnabled() {
+			log.Printf("Provider %s is disabled", provider.GetName())
+			continue
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// NewProvider creates provider declared by this configuration. Configuration has to be valid and
+// can not declare an aggregating provider, see NewAggregatingProvider. Error is returned if rate
+// files of the provider can not be loaded.
+func (p ProviderConfig) NewProvider() (converter.ConverterProvider, error) {
+	var provider converter.ConverterProvider
+	switch p.Type {
+	case converter.FixerIO:
+		provider = converter.NewFixerIOProviderWithURL(p.Endpoint, p.APIKey, p.Headers)
+	case converter.ECB:
+		provider = converter.NewECBProviderWithURLs(p.Endpoint, p.HistoryEndpoint, p.Headers)
+	case converter.Local:
+		pivot, _ := common.NormalizeCurrency(p.Pivot)
+		provider = converter.NewLocalProvider(pivot)
+		if len(p.Snapshots) > 0 {
+			interval := p.ReloadInterval.Duration
+			if interval == 0 {
+				interval = converter.DefaultSnapshotInterval
+			}
+
+			provider = converter.NewLocalProviderWithDirectory(pivot, p.Snapshots, interval)
+		}
+	case converter.CSV:
+		csvProvider, err := converter.LoadCSVProvider(p.Files, p.CSVColumns())
+		if err != nil {
+			return nil, fmt.Errorf("Provider %s has invalid rate files: %s", p.GetName(), err)
+		}
+
+		provider = csvProvider
+	}
+
+	if p.GetName() != provider.Name() {
+		return converter.NewNamedProvider(provider, p.GetName()), nil
+	}
+
+	return provider, nil
+}
+
+// NewAggregatingProvider creates aggregating provider declared by this configuration, combining
+// rates of its sources found among given providers
+func (p ProviderConfig) NewAggregatingProvider(
+	providers []converter.ConverterProvider) converter.ConverterProvider {
+
+	sources := make([]converter.ConverterProvider, 0, len(p.Sources))
+	for _, provider := range providers {
+		if contains(p.Sources, provider.Name()) {
+			sources = append(sources, provider)
+		}
+	}
+
+	return converter.NewAggregatingProvider(p.GetName(), sources, p.Aggregation)
+}
+
+// Returns problems of pivot currency. Without snapshot directory only rates of built-in bases are
+// available, so pivot has to be one of them.
+func validatePivot(prefix string, provider ProviderConfig) []string {
+	pivot, err := common.NormalizeCurrency(provider.Pivot)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid pivot: %s", prefix, err)}
+	}
+
+	bases := converter.LocalBases()
+	if len(provider.Snapshots) == 0 && !contains(bases, pivot) {
+		return []string{fmt.Sprintf("%s: pivot '%s' has to be one of built-in bases: %s", prefix,
+			pivot, strings.Join(bases, ", "))}
+	}
+
+	return nil
+}
+
+// Returns problems of rate files, which have to be given and be regular files
+func validateFiles(prefix string, files []string) []string {
+	if len(files) == 0 {
+		return []string{fmt.Sprintf("%s: invalid rate files: No CSV rate files given.", prefix)}
+	}
+
+	problems := make([]string, 0)
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+			problems = append(problems, fmt.Sprintf("%s: invalid rate files: '%s' is not a file",
+				prefix, file))
+		}
+	}
+
+	return problems
+}
+
+// Checks that endpoint, if given, is an absolute http(s) url
+func validateEndpoint(endpoint string) error {
+	if len(endpoint) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid endpoint '%s', expected absolute http(s) url", endpoint)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns problems of background refresh configuration of given provider. Positive interval or at
+// least one daily time is required, and bases if provider is remote.
+func validateRefresh(prefix string, provider ProviderConfig) []string {
+	refresh := *provider.Refresh
+	problems := make([]string, 0)
+	if refresh.Interval.Duration < 0 {
+		problems = append(problems, fmt.Sprintf("%s: refresh interval can not be negative", prefix))
+	}
+
+	if refresh.Interval.Duration == 0 && len(refresh.Times) == 0 {
+		problems = append(problems, fmt.Sprintf("%s: refresh requires an interval or times",
+			prefix))
+	}
+
+	for _, value := range refresh.Times {
+		if _, err := converter.ParseClock(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", prefix, err))
+		}
+	}
+
+	if provider.IsRemote() && len(refresh.Bases) == 0 {
+		problems = append(problems, fmt.Sprintf("%s: refresh of remote provider requires bases",
+			prefix))
+	}
+
+	for _, base := range refresh.Bases {
+		if _, err := common.NormalizeCurrency(base); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid refresh base: %s", prefix, err))
+		}
+	}
+
+	return problems
+}
+
+// Returns problems of markup rules and fee profiles. Spreads and percents have to be in [0, 100)
+// range, fixed and minimal fees can not be negative.
+func validatePricing(pricing converter.PricingConfig) []string {
+	problems := make([]string, 0)
+	for i, rule := range pricing.Markups {
+		prefix := fmt.Sprintf("pricing.markups[%d]", i)
+		for _, currency := range []string{rule.From, rule.To} {
+			if len(currency) == 0 || currency == converter.Wildcard {
+				continue
+			}
+
+			if _, err := common.NormalizeCurrency(currency); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", prefix, err))
+			}
+		}
+
+		if !isPercent(rule.Spread) {
+			problems = append(problems, fmt.Sprintf("%s: spread has to be in [0, 100) range",
+				prefix))
+		}
+	}
+
+	for name, profile := range pricing.FeeProfiles {
+		prefix := fmt.Sprintf("pricing.feeProfiles.%s", name)
+		if !isPercent(profile.Percent) {
+			problems = append(problems, fmt.Sprintf("%s: percent has to be in [0, 100) range",
+				prefix))
+		}
+
+		if profile.Fixed.Sign() < 0 || profile.Min.Sign() < 0 {
+			problems = append(problems, fmt.Sprintf("%s: fixed and minimal fee can not be "+
+				"negative", prefix))
+		}
+	}
+
+	return problems
+}
+
+// Returns problems of aggregating provider. At least two distinct enabled providers, which are not
+// aggregating ones, have to be combined. Trim has to be in [0, 50) range, weights and deviation
+// can not be negative.
+func (c Config) validateAggregation(prefix string, provider ProviderConfig) []string {
+	problems := make([]string, 0)
+	if len(provider.Sources) < 2 {
+		problems = append(problems, fmt.Sprintf("%s: at least two sources have to be aggregated",
+			prefix))
+	}
+
+	seen := make(map[string]bool)
+	for _, source := range provider.Sources {
+		if seen[source] {
+			problems = append(problems, fmt.Sprintf("%s: duplicated source '%s'", prefix, source))
+		}
+
+		seen[source] = true
+		if !c.isEnabled(source) || contains(c.aggregateNames(), source) {
+			problems = append(problems, fmt.Sprintf("%s: source '%s' is not an enabled provider",
+				prefix, source))
+		}
+	}
+
+	if provider.Refresh != nil || provider.Breaker != nil {
+		problems = append(problems, fmt.Sprintf("%s: refresh and breaker are configured on "+
+			"sources of aggregating provider", prefix))
+	}
+
+	settings := provider.Aggregation
+	if len(settings.Method) > 0 && !contains(converter.AggregateMethods, settings.Method) {
+		problems = append(problems, fmt.Sprintf("%s: unknown aggregation method '%s', expected "+
+			"one of: %s", prefix, settings.Method, strings.Join(converter.AggregateMethods, ", ")))
+	}
+
+	if settings.Trim.Sign() < 0 || settings.Trim.Cmp(common.NewDecimal(50, 0)) >= 0 {
+		problems = append(problems, fmt.Sprintf("%s: trim has to be in [0, 50) range", prefix))
+	}
+
+	if settings.MaxDeviation.Sign() < 0 {
+		problems = append(problems, fmt.Sprintf("%s: max deviation can not be negative", prefix))
+	}
+
+	for name, weight := range settings.Weights {
+		if weight.Sign() < 0 || !seen[name] {
+			problems = append(problems, fmt.Sprintf("%s: weight of '%s' has to be a non-negative "+
+				"weight of a source", prefix, name))
+		}
+	}
+
+	return problems
+}
+
+// Returns names of all declared aggregating providers
+func (c Config) aggregateNames() []string {
+	names := make([]string, 0)
+	for _, provider := range c.Providers {
+		if provider.Type == converter.Aggregate {
+			names = append(names, provider.GetName())
+		}
+	}
+
+	return names
+}
+
+func isPercent(value common.Decimal) bool {
+	return value.Sign() >= 0 && value.Cmp(common.NewDecimal(100, 0)) < 0
+}
@@ -0,0 +1,262 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/provider/converter"
+)
+
+// Writes given configuration to a temporary file and loads it
+func loadString(t *testing.T, content string) (*Config, error) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return Load(path)
+}
+
+func TestLoad(t *testing.T) {
+	config, err := loadString(t, `{
+		"defaultProvider": "primary",
+		"providers": [
+			{"name": "primary", "type": "fixerio", "endpoint": "https://data.fixer.io/api",
+				"apiKey": "secret", "timeout": "2s"},
+			{"type": "ecb", "enabled": false},
+			{"type": "local", "pivot": "usd"}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	providers := config.EnabledProviders()
+	if len(providers) != 2 {
+		t.Fatalf("EnabledProviders() == \ngot: %d providers, \nexpected 2", len(providers))
+	}
+
+	cases := []struct {
+		config          ProviderConfig
+		expectedName    string
+		expectedTimeout time.Duration
+	}{
+		{providers[0], "primary", 2 * time.Second},
+		{providers[1], "local", 0},
+	}
+
+	for _, c := range cases {
+		provider, err := c.config.NewProvider()
+		if err != nil {
+			t.Fatalf("NewProvider(%v) returned error: %v", c.config, err)
+		}
+
+		if provider.Name() != c.expectedName || c.config.Timeout.Duration != c.expectedTimeout {
+			t.Errorf("NewProvider(%v) == \ngot: %s (%s), \nexpected %s (%s)", c.config,
+				provider.Name(), c.config.Timeout, c.expectedName, c.expectedTimeout)
+		}
+	}
+}
+
+func TestNewProviderErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rates.csv")
+	if err := ioutil.WriteFile(path, []byte("date,base,quote\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := ProviderConfig{Name: "rates", Type: "csv", Files: []string{path}}
+	if err := (Config{DefaultProvider: "rates",
+		Providers: []ProviderConfig{config}}).Validate(); err != nil {
+		t.Fatalf("Validate() returned error: %v", err)
+	}
+
+	if _, err := config.NewProvider(); err == nil ||
+		!strings.Contains(err.Error(), "Provider rates has invalid rate files") {
+		t.Errorf("NewProvider(%v) == \ngot: %v, \nexpected invalid rate files error", config, err)
+	}
+}
+
+func TestLoadDefaultProvider(t *testing.T) {
+	config, err := loadString(t, `{"providers": [{"type": "ecb"}]}`)
+	if err != nil || config.DefaultProvider != "fallback" {
+		t.Errorf("Load() == \ngot: %v, %v \nexpected default provider fallback", config, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected []string
+	}{
+		{`{"providers": [`, []string{"Invalid configuration file"}},
+		{`{"providers": [{"type": "ecb", "timeout": "soon"}]}`, []string{"Invalid duration"}},
+		{`{"providers": []}`, []string{"at least one provider"}},
+		{`{"providers": [{"type": "yahoo"}]}`, []string{"unknown type 'yahoo'"}},
+		{`{"providers": [{"type": "ecb"}, {"type": "ecb", "timeout": "-1s"}]}`,
+			[]string{"duplicated name 'ecb'", "timeout can not be negative"}},
+		{`{"providers": [{"name": "fallback", "type": "local", "pivot": "XYZ"}]}`,
+			[]string{"name 'fallback' is reserved", "invalid pivot"}},
+		{`{"providers": [{"type": "local", "pivot": "chf"}]}`,
+			[]string{"pivot 'CHF' has to be one of built-in bases: EUR, PLN, USD"}},
+		{`{"providers": [{"type": "ecb", "breaker": {"failureThreshold": -1}}]}`,
+			[]string{"breaker failure threshold and cool-down can not be negative"}},
+		{`{"providers": [{"type": "local", "snapshots": "/nonexistent/snapshots",
+			"reloadInterval": "-1s"}]}`, []string{"snapshots '/nonexistent/snapshots' is not a " +
+			"directory", "reload interval can not be negative"}},
+		{`{"providers": [{"type": "ecb", "refresh": {"times": ["25:00"], "bases": ["XYZ"]}},
+			{"type": "local", "refresh": {}}]}`, []string{"Invalid time '25:00'",
+			"invalid refresh base", "refresh requires an interval or times"}},
+		{`{"providers": [{"type": "fixerio", "refresh": {"interval": "1h"}}]}`,
+			[]string{"providers[0] (fixerio): refresh of remote provider requires bases"}},
+		{`{"providers": [{"type": "csv"}, {"name": "rates", "type": "csv",
+			"files": ["/nonexistent/rates.csv"]}]}`, []string{"providers[0] (csv): invalid rate " +
+			"files: No CSV rate files given.", "providers[1] (rates): invalid rate files"}},
+		{`{"providers": [{"type": "ecb"}, {"name": "consensus", "type": "aggregate",
+			"sources": ["ecb", "ecb", "consensus", "local"], "aggregation": {"method": "mode",
+			"trim": "50", "maxDeviation": -1, "weights": {"fixerio": 1}}}]}`,
+			[]string{"duplicated source 'ecb'", "source 'consensus' is not an enabled provider",
+				"source 'local' is not an enabled provider", "unknown aggregation method 'mode'",
+				"trim has to be in [0, 50) range", "max deviation can not be negative",
+				"weight of 'fixerio' has to be"}},
+		{`{"providers": [{"type": "ecb"}, {"type": "aggregate", "sources": ["ecb"],
+			"refresh": {"interval": "1h"}}]}`, []string{"at least two sources",
+			"refresh and breaker are configured on sources"}},
+		{`{"providers": [{"type": "ecb"}], "tenants": {"acme": "key", "globex": "key"}}`,
+			[]string{"API key has to be non-empty and unique"}},
+		{`{"providers": [{"type": "fixerio", "endpoint": "api.fixer.io"}]}`,
+			[]string{"invalid endpoint 'api.fixer.io'"}},
+		{`{"defaultProvider": "ecb", "providers": [{"type": "ecb", "enabled": false},
+			{"type": "local"}]}`, []string{"default provider 'ecb' is not an enabled provider"}},
+		{`{"providers": [{"type": "ecb"}], "pricing": {"markups": [{"from": "XYZ", "to": "*",
+			"spread": "100"}], "feeProfiles": {"retail": {"percent": -1, "fixed": "-2"}}}}`,
+			[]string{"pricing.markups[0]: Currency XYZ is not a valid", "spread has to be in",
+				"pricing.feeProfiles.retail: percent has to be in",
+				"fixed and minimal fee can not be negative"}},
+		{`{"providers": [{"type": "ecb"}], "pricing": {"markups": [{"spread": "abc"}]}}`,
+			[]string{"Invalid configuration file"}},
+	}
+
+	for _, c := range cases {
+		_, err := loadString(t, c.content)
+		if err == nil {
+			t.Errorf("Load(%s) expected error", c.content)
+			continue
+		}
+
+		for _, expected := range c.expected {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Load(%s) == \ngot: %v, \nexpected error containing %s", c.content, err,
+					expected)
+			}
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	config := Default()
+	if err := config.Validate(); err != nil {
+		t.Errorf("Default().Validate() returned error: %v", err)
+	}
+
+	names := make([]string, 0)
+	for _, provider := range config.EnabledProviders() {
+		converterProvider, err := provider.NewProvider()
+		if err != nil {
+			t.Fatalf("NewProvider(%v) returned error: %v", provider, err)
+		}
+
+		names = append(names, converterProvider.Name())
+	}
+
+	if strings.Join(names, ",") != "fixerio,ecb,local" {
+		t.Errorf("Default() == \ngot: %v, \nexpected [fixerio ecb local]", names)
+	}
+}
+
+func TestIsCacheable(t *testing.T) {
+	cases := []struct {
+		providerType string
+		expected     bool
+	}{
+		{"fixerio", true},
+		{"ecb", true},
+		{"local", false},
+		{"csv", false},
+	}
+
+	for _, c := range cases {
+		actual := ProviderConfig{Type: c.providerType}.IsCacheable()
+		if actual != c.expected {
+			t.Errorf("IsCacheable(%s) == \ngot: %t, \nexpected %t", c.providerType, actual,
+				c.expected)
+		}
+	}
+}
+
+func TestBreakerSettings(t *testing.T) {
+	cases := []struct {
+		breaker  *BreakerConfig
+		expected converter.BreakerSettings
+	}{
+		{nil, converter.DefaultBreakerSettings},
+		{&BreakerConfig{FailureThreshold: 3}, converter.BreakerSettings{FailureThreshold: 3,
+			CoolDown: converter.DefaultBreakerSettings.CoolDown}},
+		{&BreakerConfig{FailureThreshold: 0, CoolDown: Duration{time.Minute}},
+			converter.BreakerSettings{FailureThreshold: 0, CoolDown: time.Minute}},
+	}
+
+	for _, c := range cases {
+		actual := ProviderConfig{Type: "ecb", Breaker: c.breaker}.BreakerSettings()
+		if actual != c.expected {
+			t.Errorf("BreakerSettings(%v) == \ngot: %v, \nexpected %v", c.breaker, actual,
+				c.expected)
+		}
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	cases := []struct {
+		columns  *converter.CSVColumns
+		expected converter.CSVColumns
+	}{
+		{nil, converter.DefaultCSVColumns},
+		{&converter.CSVColumns{Rate: "bid", Inverse: "ask", Delimiter: ";"},
+			converter.CSVColumns{Date: "date", Base: "base", Quote: "quote", Rate: "bid",
+				Inverse: "ask", Delimiter: ";"}},
+	}
+
+	for _, c := range cases {
+		actual := ProviderConfig{Type: "csv", Columns: c.columns}.CSVColumns()
+		if actual != c.expected {
+			t.Errorf("CSVColumns(%v) == \ngot: %v, \nexpected %v", c.columns, actual, c.expected)
+		}
+	}
+}
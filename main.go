@@ -13,19 +13,40 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/emicklei/go-restful"
+	"github.com/floreks/go-currency/common"
+	"github.com/floreks/go-currency/config"
+	providers "github.com/floreks/go-currency/provider/converter"
+	"github.com/floreks/go-currency/service/admin"
 	"github.com/floreks/go-currency/service/converter"
+	"github.com/floreks/go-currency/service/currency"
+	"github.com/floreks/go-currency/service/provider"
 	"github.com/spf13/pflag"
 )
 
 var (
-	argPort = pflag.Int("port", 8080, "The port to listen on for incoming HTTP requests")
+	argPort   = pflag.Int("port", 8080, "The port to listen on for incoming HTTP requests")
+	argConfig = pflag.String("config", "", "Path to the JSON configuration file declaring "+
+		"exchange rates providers. All built-in providers are enabled if empty")
+	argRounding = pflag.String("rounding", string(common.DefaultRoundingMode), "Default rounding "+
+		"mode used for converted amounts. One of: half-up, half-even, floor, ceiling, truncate")
+	argCacheTTL = pflag.Duration("cache-ttl", time.Hour, "How long exchange rates fetched from "+
+		"providers are cached. Caching is disabled if set to 0")
+	argStore = pflag.String("store", "", "Path to the file in which every exchange rates table "+
+		"fetched from providers is recorded. Enables 'store' provider. Disabled if empty")
+	argOverrides = pflag.String("overrides", "", "Path to the file in which rate overrides "+
+		"managed through the admin API are kept. Requires 'adminToken' in configuration. Admin "+
+		"API is disabled if empty")
+	argProviderTimeout = pflag.Duration("provider-timeout", 5*time.Second, "How long the "+
+		"provider that has no timeout configured is waited for")
 )
 
 func main() {
@@ -35,9 +56,179 @@ func main() {
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
 
-	// Register handler
-	restful.Add(converter.NewConverterService().Handler())
+	rounding, err := common.ParseRoundingMode(*argRounding)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.Default()
+	if *argConfig != "" {
+		if cfg, err = config.Load(*argConfig); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Register handlers
+	providerConfigs := make([]config.ProviderConfig, 0)
+	aggregateConfigs := make([]config.ProviderConfig, 0)
+	for _, providerConfig := range cfg.EnabledProviders() {
+		if providerConfig.Type == providers.Aggregate {
+			aggregateConfigs = append(aggregateConfigs, providerConfig)
+		} else {
+			providerConfigs = append(providerConfigs, providerConfig)
+		}
+	}
+
+	converterProviders := make([]providers.ConverterProvider, 0, len(providerConfigs))
+	for _, providerConfig := range providerConfigs {
+		timeout := providerConfig.Timeout.Duration
+		if timeout == 0 {
+			timeout = *argProviderTimeout
+		}
+
+		converterProvider, err := providerConfig.NewProvider()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		converterProviders = append(converterProviders,
+			providers.NewTimeoutProvider(converterProvider, timeout))
+	}
+
+	var store providers.RateStore
+	if *argStore != "" {
+		if store, err = providers.OpenFileRateStore(*argStore); err != nil {
+			log.Fatal(err)
+		}
+
+		for i, converterProvider := range converterProviders {
+			converterProviders[i] = providers.NewRecordingProvider(converterProvider, store)
+		}
+	}
+
+	// Unhealthy providers are short-circuited, so that fallback provider moves on quickly. Breakers
+	// are placed below cache, so that only actual fetches of rates change their circuits.
+	for i, converterProvider := range converterProviders {
+		converterProviders[i] = providers.NewCircuitBreakerProvider(converterProvider,
+			providerConfigs[i].BreakerSettings())
+	}
+
+	// Status of every provider is reported by providers service. Monitors are placed below cache, so
+	// that only actual fetches of rates are reported.
+	monitors := make(map[string]providers.StatusReporter)
+	for i, converterProvider := range converterProviders {
+		converterProviders[i] = monitor(monitors, converterProvider)
+	}
+
+	// Providers reading local files are not cached, so that reloaded snapshots are served at once
+	if *argCacheTTL > 0 {
+		for i, converterProvider := range converterProviders {
+			if providerConfigs[i].IsCacheable() {
+				converterProviders[i] = providers.NewCachingProvider(converterProvider,
+					*argCacheTTL)
+			}
+		}
+	}
+
+	// Latest rates of providers with refresh schedule are fetched in the background. Prefetchers
+	// are placed above cache, so that refreshed tables are served at once and replace cached ones.
+	refreshers := make(map[string]providers.RefreshReporter)
+	for i, converterProvider := range converterProviders {
+		refresh := providerConfigs[i].Refresh
+		if refresh == nil {
+			continue
+		}
+
+		// Remote providers are validated to declare their bases
+		bases := refresh.Bases
+		if len(bases) == 0 {
+			bases = converterProvider.Capabilities().Bases
+		}
+
+		prefetching := providers.NewPrefetchingProvider(converterProvider, bases,
+			refresh.Schedule())
+		go prefetching.Run(context.Background())
+		refreshers[converterProvider.Name()] = prefetching
+		converterProviders[i] = prefetching
+	}
+
+	// Aggregating providers combine rates of other providers, which are already cached and guarded
+	sources := len(converterProviders)
+	aggregatedProviders := make([]providers.ConverterProvider, sources)
+	copy(aggregatedProviders, converterProviders)
+	for _, aggregateConfig := range aggregateConfigs {
+		converterProviders = append(converterProviders, monitor(monitors,
+			aggregateConfig.NewAggregatingProvider(aggregatedProviders)))
+	}
+
+	// Overrides are applied on top of cached rates, so that their changes apply immediately
+	var overrides providers.OverrideStore
+	if *argOverrides != "" {
+		if cfg.AdminToken == "" {
+			log.Fatal("Admin API requires 'adminToken' in configuration.")
+		}
+
+		if overrides, err = providers.OpenFileOverrideStore(*argOverrides); err != nil {
+			log.Fatal(err)
+		}
+
+		for i, converterProvider := range converterProviders {
+			converterProviders[i] = providers.NewOverridingProvider(converterProvider, overrides)
+		}
+	}
+
+	// Default provider tries all providers in order until one of them serves rates
+	fallbackProviders := make([]providers.ConverterProvider, sources)
+	copy(fallbackProviders, converterProviders[:sources])
+	converterProviders = append(converterProviders,
+		monitor(monitors, providers.NewFallbackProvider(fallbackProviders)))
+
+	// Stored rates are read from disk directly, there is no need to cache them
+	if store != nil {
+		var storeProvider providers.ConverterProvider = providers.NewStoreProvider(store)
+		if overrides != nil {
+			storeProvider = providers.NewOverridingProvider(storeProvider, overrides)
+		}
+
+		converterProviders = append(converterProviders, monitor(monitors, storeProvider))
+	}
+
+	if !isServed(converterProviders, cfg.DefaultProvider) {
+		log.Fatalf("Default provider %s is not served. Enable it or change default provider.",
+			cfg.DefaultProvider)
+	}
+
+	converterService := converter.NewConverterService(converterProviders, cfg.DefaultProvider,
+		rounding, providers.NewPricer(cfg.Pricing), cfg.TenantKeys())
+	restful.Add(converterService.Handler())
+	restful.Add(converterService.TimeSeriesHandler())
+	restful.Add(currency.NewCurrencyService().Handler())
+	restful.Add(provider.NewProviderService(converterProviders, cfg.DefaultProvider,
+		monitors, refreshers).Handler())
+	if overrides != nil {
+		restful.Add(admin.NewAdminService(overrides, cfg.AdminToken).Handler())
+	}
 
 	log.Printf("Listening on port: %d", *argPort)
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *argPort), nil))
 }
+
+// Returns true if provider with given name is one of given providers
+func isServed(converterProviders []providers.ConverterProvider, name string) bool {
+	for _, converterProvider := range converterProviders {
+		if converterProvider.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns given provider keeping track of its status, which is registered in given monitors
+func monitor(monitors map[string]providers.StatusReporter,
+	converterProvider providers.ConverterProvider) providers.ConverterProvider {
+
+	monitoring := providers.NewMonitoringProvider(converterProvider)
+	monitors[monitoring.Name()] = monitoring
+	return monitoring
+}
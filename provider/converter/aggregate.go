@@ -0,0 +1,300 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Methods combining rates of aggregated providers
+const (
+	AggregateMedian      = "median"
+	AggregateTrimmedMean = "trimmed-mean"
+	AggregateWeighted    = "weighted"
+)
+
+// AggregateMethods - methods that can be used by aggregating provider
+var AggregateMethods = []string{AggregateMedian, AggregateTrimmedMean, AggregateWeighted}
+
+// AggregationSettings configures how aggregating provider combines rates of its providers
+type AggregationSettings struct {
+	// Method - median, trimmed-mean or weighted, median if empty
+	Method string `json:"method"`
+	// Trim - percent of the lowest and of the highest rates dropped by trimmed mean
+	Trim common.Decimal `json:"trim"`
+	// Weights of rates by provider name used by weighted average, 1 if not given
+	Weights map[string]common.Decimal `json:"weights"`
+	// MaxDeviation - percent by which rate can differ from median of all rates before it is
+	// excluded as an outlier, outliers are not excluded if zero
+	MaxDeviation common.Decimal `json:"maxDeviation"`
+}
+
+// Aggregation describes how rate of a single currency was combined
+type Aggregation struct {
+	// Sources - number of providers whose rates were combined
+	Sources int `json:"sources" xml:"sources"`
+	// Excluded - number of rates excluded as outliers
+	Excluded int `json:"excluded,omitempty" xml:"excluded,omitempty"`
+	// Derived - number of combined rates derived through pivot currency by their providers
+	Derived int `json:"derived,omitempty" xml:"derived,omitempty"`
+	// Spread - difference between the highest and the lowest combined rate
+	Spread common.Decimal `json:"spread" xml:"spread"`
+}
+
+// Aggregations is a map of rate aggregations keyed by currency code.
+type Aggregations map[string]Aggregation
+
+// MarshalXML implements xml.Marshaler. Every aggregation is marshalled as an element named after
+// its currency.
+func (a Aggregations) MarshalXML(enc *xml.Encoder, startElem xml.StartElement) error {
+	if err := enc.EncodeToken(startElem); err != nil {
+		return err
+	}
+
+	currencies := make([]string, 0, len(a))
+	for currency := range a {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		elem := xml.StartElement{Name: xml.Name{Local: currency}}
+		if err := enc.EncodeElement(a[currency], elem); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(startElem.End())
+}
+
+// Rate published by a single aggregated provider
+type sourceRate struct {
+	provider string
+	rate     common.Decimal
+	// Pivot through which provider derived the rate, empty if it was published directly
+	pivot string
+}
+
+// Sorts rates in ascending order
+type byRate []sourceRate
+
+func (b byRate) Len() int           { return len(b) }
+func (b byRate) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byRate) Less(i, j int) bool { return b[i].rate.Cmp(b[j].rate) < 0 }
+
+// AggregatingProvider asks several providers for rates concurrently and combines rates of every
+// currency by median, trimmed mean or weighted average, so that no single provider is trusted.
+// Rates deviating too much from the median are excluded. Implements ConverterProvider interface.
+type AggregatingProvider struct {
+	name      string
+	providers []ConverterProvider
+	settings  AggregationSettings
+}
+
+// Name returns name of this provider
+func (a AggregatingProvider) Name() string {
+	return a.name
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (a AggregatingProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := a.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns rates combined out of rates of the newest date served by providers. Providers that
+// served older rates are reported as failed, so that rates of different days are never combined.
+// Pivot of the first provider that derived its rates is reported. Error is returned only if none
+// of providers served rates.
+func (a AggregatingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	tables := make([]*ExchangeRates, len(a.providers))
+	errs := make([]error, len(a.providers))
+	wg := new(sync.WaitGroup)
+	for i, provider := range a.providers {
+		wg.Add(1)
+		go func(i int, provider ConverterProvider) {
+			defer wg.Done()
+			tables[i], errs[i] = provider.Rates(ctx, base, symbols, date)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	result := &ExchangeRates{Base: base, Failures: make(ProviderFailures, 0), Rates: make(Rates),
+		Aggregations: make(Aggregations)}
+	for i, table := range tables {
+		if errs[i] == nil && table.Date > result.Date {
+			result.Date = table.Date
+		}
+	}
+
+	sources := make(map[string][]sourceRate)
+	timeouts := 0
+	for i, table := range tables {
+		name := a.providers[i].Name()
+		if errs[i] == nil && table.Date != result.Date {
+			errs[i] = common.NewError(common.ErrRateNotFound,
+				"Provider %s served rates of %s instead of %s.", name, table.Date, result.Date)
+		}
+
+		if errs[i] != nil {
+			log.Printf("Aggregating provider %s - %s failed: %s", a.name, name, errs[i])
+			clientErr := common.AsError(errs[i])
+			result.Failures = append(result.Failures, ProviderFailure{name, clientErr.Code,
+				clientErr.Message})
+			if IsTimeout(errs[i]) {
+				timeouts++
+			}
+
+			continue
+		}
+
+		if result.Pivot == "" {
+			result.Pivot = table.Pivot
+		}
+
+		for symbol, rate := range table.Rates {
+			sources[symbol] = append(sources[symbol], sourceRate{name, rate, table.Pivot})
+		}
+	}
+
+	if len(result.Failures) == len(a.providers) {
+		return nil, &FallbackError{Base: base, Failures: result.Failures,
+			timeout: timeouts == len(result.Failures)}
+	}
+
+	for symbol, rates := range sources {
+		result.Rates[symbol], result.Aggregations[symbol] = a.aggregate(rates)
+	}
+
+	return result, nil
+}
+
+// Capabilities returns currencies served by any of the providers
+func (a AggregatingProvider) Capabilities() Capabilities {
+	capabilities := make([]Capabilities, 0, len(a.providers))
+	for _, provider := range a.providers {
+		capabilities = append(capabilities, provider.Capabilities())
+	}
+
+	return mergeCapabilities(capabilities...)
+}
+
+// Combines rates of a single currency with configured method once outliers are excluded
+func (a AggregatingProvider) aggregate(rates []sourceRate) (common.Decimal, Aggregation) {
+	sort.Sort(byRate(rates))
+	median := medianRate(rates)
+
+	included := rates
+	if a.settings.MaxDeviation.Sign() > 0 {
+		included = make([]sourceRate, 0, len(rates))
+		for _, rate := range rates {
+			deviation := rate.rate.Sub(median).Abs().Mul(hundred)
+			if deviation.Cmp(median.Mul(a.settings.MaxDeviation)) <= 0 {
+				included = append(included, rate)
+			}
+		}
+
+		// Both middle rates of an even number of rates deviate from their median equally, so all
+		// of them may be excluded. None of them is an outlier then.
+		if len(included) == 0 {
+			included = rates
+		}
+	}
+
+	aggregation := Aggregation{Sources: len(included), Excluded: len(rates) - len(included),
+		Spread: included[len(included)-1].rate.Sub(included[0].rate)}
+	for _, rate := range included {
+		if rate.pivot != "" {
+			aggregation.Derived++
+		}
+	}
+
+	switch a.settings.Method {
+	case AggregateTrimmedMean:
+		trimmed := int(common.NewDecimal(int64(len(included)), 0).Mul(a.settings.Trim).Quo(hundred,
+			0, common.Floor).Float64())
+		if 2*trimmed >= len(included) {
+			trimmed = (len(included) - 1) / 2
+		}
+
+		return meanRate(included[trimmed:len(included)-trimmed], nil), aggregation
+	case AggregateWeighted:
+		return meanRate(included, a.settings.Weights), aggregation
+	default:
+		return medianRate(included), aggregation
+	}
+}
+
+// Returns median of given rates sorted in ascending order. Median of an even number of rates is
+// exact, as halving their sum needs at most one more decimal place.
+func medianRate(rates []sourceRate) common.Decimal {
+	middle := len(rates) / 2
+	if len(rates)%2 == 1 {
+		return rates[middle].rate
+	}
+
+	sum := rates[middle-1].rate.Add(rates[middle].rate)
+	return sum.Quo(common.NewDecimal(2, 0), sum.Scale()+1, common.HalfEven)
+}
+
+// Returns mean of given rates weighted by weights of their providers. Rates of providers without
+// weight have weight 1, mean of all rates is returned if total weight is zero.
+func meanRate(rates []sourceRate, weights map[string]common.Decimal) common.Decimal {
+	sum, total := common.Decimal{}, common.Decimal{}
+	for _, rate := range rates {
+		weight, exists := weights[rate.provider]
+		if !exists {
+			weight = common.NewDecimal(1, 0)
+		}
+
+		sum = sum.Add(rate.rate.Mul(weight))
+		total = total.Add(weight)
+	}
+
+	if total.IsZero() {
+		return meanRate(rates, nil)
+	}
+
+	if len(rates) == 1 {
+		return rates[0].rate
+	}
+
+	return sum.Quo(total, crossRateScale, common.HalfEven)
+}
+
+// NewAggregatingProvider returns provider with given name combining rates of given providers
+func NewAggregatingProvider(name string, providers []ConverterProvider,
+	settings AggregationSettings) AggregatingProvider {
+
+	return AggregatingProvider{name: name, providers: providers, settings: settings}
+}
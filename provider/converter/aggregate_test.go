@@ -0,0 +1,179 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Provider serving given rates of every base currency
+type staticProvider struct {
+	dailyProvider
+	name  string
+	date  string
+	pivot string
+	rates map[string]string
+}
+
+func (s staticProvider) Name() string {
+	return s.name
+}
+
+func (s staticProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	rates := make(Rates, len(s.rates))
+	for symbol, rate := range s.rates {
+		rates[symbol] = common.MustParseDecimal(rate)
+	}
+
+	return &ExchangeRates{Base: base, Date: s.date, Pivot: s.pivot,
+		Rates: selectRates(rates, base, symbols)}, nil
+}
+
+func TestAggregatingProviderRates(t *testing.T) {
+	providers := []ConverterProvider{
+		staticProvider{name: "a", date: "2020-01-02", rates: map[string]string{"EUR": "4.30",
+			"USD": "3.80"}},
+		staticProvider{name: "b", date: "2020-01-02", rates: map[string]string{"EUR": "4.32",
+			"USD": "3.90"}},
+		staticProvider{name: "c", date: "2020-01-02", rates: map[string]string{"EUR": "4.80"}},
+		staticProvider{name: "d", date: "2020-01-02", rates: map[string]string{"EUR": "4.31"}},
+		failingProvider{name: "e", err: errors.New("Connection refused.")},
+	}
+
+	cases := []struct {
+		settings     AggregationSettings
+		expected     map[string]string
+		aggregations map[string]string
+	}{
+		{AggregationSettings{}, map[string]string{"EUR": "4.315", "USD": "3.85"},
+			map[string]string{"EUR": "4 0 0.50", "USD": "2 0 0.10"}},
+		{AggregationSettings{MaxDeviation: common.NewDecimal(5, 0)},
+			map[string]string{"EUR": "4.31", "USD": "3.85"},
+			map[string]string{"EUR": "3 1 0.02", "USD": "2 0 0.10"}},
+		{AggregationSettings{Method: AggregateTrimmedMean, Trim: common.NewDecimal(25, 0)},
+			map[string]string{"EUR": "4.315", "USD": "3.85"},
+			map[string]string{"EUR": "4 0 0.50", "USD": "2 0 0.10"}},
+		{AggregationSettings{Method: AggregateWeighted, MaxDeviation: common.NewDecimal(5, 0),
+			Weights: map[string]common.Decimal{"a": common.NewDecimal(3, 0),
+				"b": common.NewDecimal(0, 0)}},
+			map[string]string{"EUR": "4.3025", "USD": "3.8"},
+			map[string]string{"EUR": "3 1 0.02", "USD": "2 0 0.10"}},
+	}
+
+	for _, c := range cases {
+		provider := NewAggregatingProvider("consensus", providers, c.settings)
+		rates, err := provider.Rates(context.Background(), "PLN", nil, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, aggregations := make(map[string]string), make(map[string]string)
+		for symbol, rate := range rates.Rates {
+			actual[symbol] = rate.String()
+			if rate.Cmp(common.MustParseDecimal(c.expected[symbol])) == 0 {
+				actual[symbol] = c.expected[symbol]
+			}
+
+			aggregation := rates.Aggregations[symbol]
+			aggregations[symbol] = fmt.Sprintf("%d %d %s", aggregation.Sources,
+				aggregation.Excluded, aggregation.Spread)
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) ||
+			!reflect.DeepEqual(aggregations, c.aggregations) || rates.Date != "2020-01-02" ||
+			len(rates.Failures) != 1 {
+			t.Errorf("Rates(%v) == \ngot: %v %v %s %v, \nexpected %v %v", c.settings, actual,
+				aggregations, rates.Date, rates.Failures, c.expected, c.aggregations)
+		}
+	}
+}
+
+func TestAggregatingProviderDates(t *testing.T) {
+	provider := NewAggregatingProvider("consensus", []ConverterProvider{
+		staticProvider{name: "a", date: "2020-01-02", rates: map[string]string{"EUR": "4.30"}},
+		staticProvider{name: "b", date: "2020-01-03", rates: map[string]string{"EUR": "4.32"}},
+		staticProvider{name: "c", date: "2020-01-03", pivot: "USD",
+			rates: map[string]string{"EUR": "4.34"}},
+	}, AggregationSettings{})
+
+	rates, err := provider.Rates(context.Background(), "PLN", []string{"EUR"}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggregation := rates.Aggregations["EUR"]
+	actual := fmt.Sprintf("%s %s %s %d %d", rates.Date, rates.Pivot, rates.Rates["EUR"],
+		aggregation.Sources, aggregation.Derived)
+	expected := "2020-01-03 USD 4.330 2 1"
+	if actual != expected || len(rates.Failures) != 1 || rates.Failures[0].Provider != "a" ||
+		rates.Failures[0].Code != common.ErrRateNotFound {
+		t.Errorf("Rates(PLN) == \ngot: %s %v, \nexpected %s", actual, rates.Failures, expected)
+	}
+}
+
+func TestAggregatingProviderEvenSources(t *testing.T) {
+	providers := []ConverterProvider{
+		staticProvider{name: "a", date: "2020-01-02", rates: map[string]string{"EUR": "4.30"}},
+		staticProvider{name: "b", date: "2020-01-02", rates: map[string]string{"EUR": "4.50"}},
+	}
+
+	cases := []struct {
+		settings    AggregationSettings
+		expected    string
+		aggregation string
+	}{
+		{AggregationSettings{MaxDeviation: common.NewDecimal(1, 0)}, "4.4", "2 0 0.20"},
+		{AggregationSettings{Method: AggregateWeighted, MaxDeviation: common.NewDecimal(1, 0)},
+			"4.4", "2 0 0.20"},
+	}
+
+	for _, c := range cases {
+		provider := NewAggregatingProvider("consensus", providers, c.settings)
+		rates, err := provider.Rates(context.Background(), "PLN", []string{"EUR"}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		aggregation := rates.Aggregations["EUR"]
+		actual := fmt.Sprintf("%d %d %s", aggregation.Sources, aggregation.Excluded,
+			aggregation.Spread)
+		rate := rates.Rates["EUR"]
+		if rate.Cmp(common.MustParseDecimal(c.expected)) != 0 || actual != c.aggregation {
+			t.Errorf("Rates(%v) == \ngot: %s %s, \nexpected %s %s", c.settings, rate, actual,
+				c.expected, c.aggregation)
+		}
+	}
+}
+
+func TestAggregatingProviderFailure(t *testing.T) {
+	provider := NewAggregatingProvider("consensus", []ConverterProvider{
+		failingProvider{name: "a", err: common.NewError(common.ErrUnsupportedCurrency,
+			"Currency XYZ not supported.")},
+		failingProvider{name: "b", err: common.NewError(common.ErrUnsupportedCurrency,
+			"Currency XYZ not supported.")},
+	}, AggregationSettings{})
+
+	_, err := provider.Rates(context.Background(), "XYZ", nil, time.Time{})
+	if actual := common.AsError(err).Code; actual != common.ErrUnsupportedCurrency {
+		t.Errorf("Rates(XYZ) == \ngot: %v, \nexpected %s", err, common.ErrUnsupportedCurrency)
+	}
+}
@@ -0,0 +1,92 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import "context"
+
+// Requests converted with a single exchange rates lookup
+type batchGroup struct {
+	base    string
+	request ConverterRequest
+	indexes []int
+	symbols map[string]bool
+	all     bool
+}
+
+// ConvertBatch converts every request with given provider. Requests with the same currency and date
+// share a single exchange rates lookup of all their target currencies. Responses and errors are
+// returned in order of requests, exactly one of them is set for every request.
+func ConvertBatch(ctx context.Context, provider ConverterProvider,
+	requests []ConverterRequest) ([]*ConverterResponse, []error) {
+
+	responses := make([]*ConverterResponse, len(requests))
+	errs := make([]error, len(requests))
+
+	groups := make([]*batchGroup, 0)
+	groupsByKey := make(map[string]*batchGroup)
+	for i, request := range requests {
+		key := request.Currency + "/" + request.Date.Format(DateFormat)
+		group, exists := groupsByKey[key]
+		if !exists {
+			group = &batchGroup{base: request.Currency, request: request,
+				symbols: make(map[string]bool)}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+
+		group.indexes = append(group.indexes, i)
+		group.all = group.all || len(request.Symbols) == 0
+		for _, symbol := range request.Symbols {
+			group.symbols[symbol] = true
+		}
+	}
+
+	for _, group := range groups {
+		var symbols []string
+		if !group.all {
+			symbols = sortedCurrencies(group.symbols)
+		}
+
+		err := ctx.Err()
+		var rates *ExchangeRates
+		if err == nil {
+			rates, err = provider.Rates(ctx, group.base, symbols, group.request.Date)
+		}
+
+		for _, i := range group.indexes {
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+
+			responses[i], errs[i] = convertBatchRequest(requests[i], rates, provider.Name())
+		}
+	}
+
+	return responses, errs
+}
+
+// Converts request with rates shared by requests of the same batch group
+func convertBatchRequest(request ConverterRequest, rates *ExchangeRates,
+	providerName string) (*ConverterResponse, error) {
+
+	selected := selectRates(rates.Rates, request.Currency, request.Symbols)
+	if err := RequireSymbols(selected, request.Currency, request.Symbols,
+		providerName); err != nil {
+		return nil, err
+	}
+
+	requestRates := *rates
+	requestRates.Rates = selected
+	return convertRequest(request, &requestRates), nil
+}
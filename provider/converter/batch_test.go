@@ -0,0 +1,82 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+func TestConvertBatch(t *testing.T) {
+	calls := new(int)
+	date := time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC)
+	requests := []ConverterRequest{
+		{Amount: common.NewDecimal(10, 0), Currency: "PLN", Symbols: []string{"USD"}, Date: date,
+			Rounding: common.HalfUp},
+		{Amount: common.NewDecimal(2, 0), Currency: "PLN", Symbols: []string{"USD"}, Date: date,
+			Rounding: common.HalfUp},
+		{Amount: common.NewDecimal(1, 0), Currency: "EUR", Date: date, Rounding: common.HalfUp},
+		{Amount: common.NewDecimal(1, 0), Currency: "PLN", Symbols: []string{"GBP"}, Date: date,
+			Rounding: common.HalfUp},
+	}
+	cases := []struct {
+		expected string
+		code     common.ErrorCode
+	}{
+		{"310.00", ""},
+		{"62.00", ""},
+		{"31.00", ""},
+		{"", common.ErrRateNotFound},
+	}
+
+	responses, errs := ConvertBatch(context.Background(), dailyProvider{calls}, requests)
+	for i, c := range cases {
+		if c.code != "" {
+			if responses[i] != nil || errs[i] == nil || common.AsError(errs[i]).Code != c.code {
+				t.Errorf("ConvertBatch(%v) == \ngot: %v, %v \nexpected %s", requests[i],
+					responses[i], errs[i], c.code)
+			}
+			continue
+		}
+
+		if errs[i] != nil || responses[i].Converted["USD"].String() != c.expected {
+			t.Errorf("ConvertBatch(%v) == \ngot: %v, %v \nexpected %s", requests[i],
+				responses[i], errs[i], c.expected)
+		}
+	}
+
+	if *calls != 2 {
+		t.Errorf("ConvertBatch() made %d rates lookups, expected 2", *calls)
+	}
+}
+
+func TestConvertBatchFailure(t *testing.T) {
+	failure := errors.New("Unavailable.")
+	provider := failingProvider{dailyProvider{new(int)}, "failing", failure}
+	requests := []ConverterRequest{
+		{Amount: common.NewDecimal(1, 0), Currency: "PLN"},
+		{Amount: common.NewDecimal(1, 0), Currency: "EUR"},
+	}
+
+	responses, errs := ConvertBatch(context.Background(), provider, requests)
+	for i := range requests {
+		if responses[i] != nil || errs[i] != failure {
+			t.Errorf("ConvertBatch(%v) == \ngot: %v, %v \nexpected %v", requests[i],
+				responses[i], errs[i], failure)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// States of a circuit breaker
+const (
+	// CircuitClosed - requests are passed to the provider
+	CircuitClosed = "closed"
+	// CircuitOpen - requests are rejected without asking the provider
+	CircuitOpen = "open"
+	// CircuitHalfOpen - a single trial request is passed to the provider
+	CircuitHalfOpen = "half-open"
+)
+
+// DefaultBreakerSettings open the circuit after 5 consecutive failures for 30 seconds
+var DefaultBreakerSettings = BreakerSettings{FailureThreshold: 5, CoolDown: 30 * time.Second}
+
+// BreakerSettings configure when circuit breaker opens and for how long
+type BreakerSettings struct {
+	// FailureThreshold - number of consecutive upstream failures after which circuit opens
+	FailureThreshold int
+	// CoolDown - how long circuit stays open before a trial request is let through
+	CoolDown time.Duration
+}
+
+// CircuitReporter is implemented by providers guarded by a circuit breaker
+type CircuitReporter interface {
+	// Circuit returns current state of the circuit breaker
+	Circuit() string
+}
+
+// CircuitOpenError is returned for requests rejected by an open circuit breaker
+type CircuitOpenError struct {
+	// Provider - name of the rejected provider
+	Provider string
+	// RetryAfter - time left until a trial request is let through
+	RetryAfter time.Duration
+}
+
+// Error implements error interface
+func (c *CircuitOpenError) Error() string {
+	return fmt.Sprintf("Provider %s is unavailable. Retry in %d seconds.", c.Provider,
+		c.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds returns time left until a trial request is let through in whole seconds
+func (c *CircuitOpenError) RetryAfterSeconds() int {
+	return int(math.Ceil(c.RetryAfter.Seconds()))
+}
+
+// Describe returns error returned to clients
+func (c *CircuitOpenError) Describe() *common.Error {
+	return &common.Error{Code: common.ErrProviderUnavailable, Message: c.Error()}
+}
+
+// Mutable state of a circuit breaker
+type circuit struct {
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// CircuitBreakerProvider wraps any provider and stops asking it for rates for a while after it
+// failed a number of times in a row. Only upstream failures (network errors, 429 and 5xx
+// responses, timeouts) are counted. Implements ConverterProvider, TimeSeriesProvider and
+// CircuitReporter interfaces.
+type CircuitBreakerProvider struct {
+	provider ConverterProvider
+	settings BreakerSettings
+
+	mu      *sync.Mutex
+	circuit *circuit
+	now     func() time.Time
+}
+
+// Name returns name of the wrapped provider
+func (c CircuitBreakerProvider) Name() string {
+	return c.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (c CircuitBreakerProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := c.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of the wrapped provider or CircuitOpenError if circuit is open
+func (c CircuitBreakerProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	probe, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := c.provider.Rates(ctx, base, symbols, date)
+	c.record(ctx, probe, err)
+	return rates, err
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+// or CircuitOpenError if circuit is open
+func (c CircuitBreakerProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	probe, err := c.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := TimeSeries(ctx, c.provider, base, symbols, start, end)
+	c.record(ctx, probe, err)
+	return series, err
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (c CircuitBreakerProvider) Capabilities() Capabilities {
+	return c.provider.Capabilities()
+}
+
+// Circuit returns current state of the circuit breaker
+func (c CircuitBreakerProvider) Circuit() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.circuit.state
+}
+
+// Decides whether request can be passed to the provider. Open circuit becomes half-open after its
+// cool-down and lets a single trial request through, for which true is returned.
+func (c CircuitBreakerProvider) allow() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.circuit.state == CircuitOpen {
+		if wait := c.circuit.openedAt.Add(c.settings.CoolDown).Sub(c.now()); wait > 0 {
+			return false, &CircuitOpenError{Provider: c.Name(), RetryAfter: wait}
+		}
+
+		log.Printf("Circuit of %s provider is half-open", c.Name())
+		c.circuit.state = CircuitHalfOpen
+	}
+
+	if c.circuit.state == CircuitHalfOpen {
+		if c.circuit.probing {
+			return false, &CircuitOpenError{Provider: c.Name(), RetryAfter: time.Second}
+		}
+
+		c.circuit.probing = true
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Updates circuit with outcome of a request passed to the provider. Only the trial request decides
+// about half-open circuit, requests admitted before circuit opened do not change it anymore.
+func (c CircuitBreakerProvider) record(ctx context.Context, probe bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.circuit.probing = false
+	} else if c.circuit.state != CircuitClosed {
+		return
+	}
+
+	// Requests abandoned by clients say nothing about health of the provider
+	if err != nil && ctx.Err() == context.Canceled {
+		return
+	}
+
+	if !isUpstreamFailure(err) {
+		if probe {
+			log.Printf("Circuit of %s provider is closed", c.Name())
+		}
+
+		c.circuit.state = CircuitClosed
+		c.circuit.failures = 0
+		return
+	}
+
+	c.circuit.failures++
+	if probe || c.circuit.failures >= c.settings.FailureThreshold {
+		log.Printf("Circuit of %s provider is open for %s after %d failures: %s", c.Name(),
+			c.settings.CoolDown, c.circuit.failures, err)
+		c.circuit.state = CircuitOpen
+		c.circuit.openedAt = c.now()
+	}
+}
+
+// Returns true if error was caused by an unavailable upstream rather than by the request itself
+func isUpstreamFailure(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *common.HTTPError:
+		return e.Temporary()
+	case net.Error:
+		return true
+	case *common.Error:
+		return e.Code == common.ErrUpstreamUnavailable || e.Code == common.ErrUpstreamTimeout
+	}
+
+	return IsTimeout(err)
+}
+
+// NewCircuitBreakerProvider returns provider guarding given provider with a circuit breaker
+// configured with given settings. Given provider is returned as it is if failure threshold is not
+// positive.
+func NewCircuitBreakerProvider(provider ConverterProvider,
+	settings BreakerSettings) ConverterProvider {
+
+	if settings.FailureThreshold <= 0 {
+		return provider
+	}
+
+	return CircuitBreakerProvider{provider: provider, settings: settings, mu: new(sync.Mutex),
+		circuit: &circuit{state: CircuitClosed}, now: time.Now}
+}
@@ -0,0 +1,164 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Provider failing rates requests with given error while its flag is set. Failed requests are
+// counted as well.
+type unavailableProvider struct {
+	dailyProvider
+	failing *bool
+	err     error
+}
+
+func (u unavailableProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	if *u.failing {
+		*u.calls++
+		return nil, u.err
+	}
+
+	return u.dailyProvider.Rates(ctx, base, symbols, date)
+}
+
+func TestCircuitBreakerProvider(t *testing.T) {
+	failing, calls := new(bool), new(int)
+	upstream := unavailableProvider{dailyProvider{calls}, failing,
+		&common.HTTPError{URL: "http://localhost", StatusCode: 503}}
+	provider := NewCircuitBreakerProvider(upstream, BreakerSettings{FailureThreshold: 2,
+		CoolDown: time.Minute}).(CircuitBreakerProvider)
+	now := time.Date(2016, 10, 31, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	cases := []struct {
+		failing         bool
+		after           time.Duration
+		expectedCalls   int
+		expectedOpenErr bool
+		expectedCircuit string
+	}{
+		{true, 0, 1, false, CircuitClosed},
+		{false, 0, 2, false, CircuitClosed},
+		{true, 0, 3, false, CircuitClosed},
+		{true, 0, 4, false, CircuitOpen},
+		{false, 0, 4, true, CircuitOpen},
+		{false, 30 * time.Second, 4, true, CircuitOpen},
+		{true, 30 * time.Second, 5, false, CircuitOpen},
+		{false, 30 * time.Second, 5, true, CircuitOpen},
+		{false, 30 * time.Second, 6, false, CircuitClosed},
+		{false, 0, 7, false, CircuitClosed},
+	}
+
+	for i, c := range cases {
+		*failing = c.failing
+		now = now.Add(c.after)
+		_, err := provider.Rates(context.Background(), "PLN", nil, time.Time{})
+		_, openErr := err.(*CircuitOpenError)
+		if *calls != c.expectedCalls || openErr != c.expectedOpenErr ||
+			provider.Circuit() != c.expectedCircuit {
+			t.Errorf("CircuitBreakerProvider.Rates() #%d == \ngot: %d calls, %v, %s \nexpected "+
+				"%d calls (open error: %t), %s", i, *calls, err, provider.Circuit(),
+				c.expectedCalls, c.expectedOpenErr, c.expectedCircuit)
+		}
+	}
+}
+
+func TestCircuitBreakerLateRequests(t *testing.T) {
+	provider := NewCircuitBreakerProvider(dailyProvider{new(int)}, BreakerSettings{
+		FailureThreshold: 1, CoolDown: time.Minute}).(CircuitBreakerProvider)
+	now := time.Date(2016, 10, 31, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+	ctx, failure := context.Background(), &common.HTTPError{StatusCode: 503}
+
+	// Both requests are admitted while circuit is closed, the first one opens it
+	late, _ := provider.allow()
+	provider.allow()
+	provider.record(ctx, false, failure)
+	now = now.Add(time.Minute)
+	probe, _ := provider.allow()
+
+	cases := []struct {
+		probe           bool
+		err             error
+		expectedCircuit string
+		expectedProbing bool
+	}{
+		{late, nil, CircuitHalfOpen, true},
+		{late, failure, CircuitHalfOpen, true},
+		{probe, nil, CircuitClosed, false},
+	}
+
+	for i, c := range cases {
+		provider.record(ctx, c.probe, c.err)
+		if _, err := provider.allow(); provider.Circuit() != c.expectedCircuit ||
+			(err != nil) != c.expectedProbing {
+			t.Errorf("CircuitBreakerProvider.record() #%d == \ngot: %s (%v), \nexpected %s "+
+				"(probing: %t)", i, provider.Circuit(), err, c.expectedCircuit,
+				c.expectedProbing)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresRequestErrors(t *testing.T) {
+	failing := new(bool)
+	*failing = true
+
+	cases := []struct {
+		err             error
+		expectedCircuit string
+	}{
+		{errors.New("Currency XYZ not supported."), CircuitClosed},
+		{&common.HTTPError{StatusCode: 404}, CircuitClosed},
+		{&common.HTTPError{StatusCode: 429}, CircuitOpen},
+		{&TimeoutError{Provider: "daily", Limit: time.Second}, CircuitOpen},
+	}
+
+	for _, c := range cases {
+		provider := NewCircuitBreakerProvider(unavailableProvider{dailyProvider{new(int)}, failing,
+			c.err}, BreakerSettings{FailureThreshold: 1, CoolDown: time.Minute})
+		provider.Rates(context.Background(), "PLN", nil, time.Time{})
+
+		if circuit := provider.(CircuitReporter).Circuit(); circuit != c.expectedCircuit {
+			t.Errorf("CircuitBreakerProvider.Rates() failing with %v == \ngot: %s, \nexpected %s",
+				c.err, circuit, c.expectedCircuit)
+		}
+	}
+}
+
+func TestCircuitOpenErrorRetryAfterSeconds(t *testing.T) {
+	cases := []struct {
+		retryAfter time.Duration
+		expected   int
+	}{
+		{30 * time.Second, 30},
+		{1500 * time.Millisecond, 2},
+		{time.Millisecond, 1},
+	}
+
+	for _, c := range cases {
+		err := &CircuitOpenError{Provider: "daily", RetryAfter: c.retryAfter}
+		if actual := err.RetryAfterSeconds(); actual != c.expected {
+			t.Errorf("RetryAfterSeconds(%s) == \ngot: %d, \nexpected %d", c.retryAfter, actual,
+				c.expected)
+		}
+	}
+}
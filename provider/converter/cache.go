@@ -0,0 +1,229 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Cache statuses reported in responses
+const (
+	CacheHit  = "HIT"
+	CacheMiss = "MISS"
+)
+
+// Key used in place of date for latest exchange rates
+const cacheKeyLatest = "latest"
+
+// Context key of requests that replace cached rates with freshly fetched ones
+type refreshKey struct{}
+
+// Returns context of a request that bypasses cached rates and caches freshly fetched ones instead
+func withRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// Upper bound of a shared fetch. Fetches are usually limited earlier by the wrapped provider.
+const maxFetchDuration = time.Minute
+
+// Exchange rates table stored in cache
+type cacheEntry struct {
+	rates   *ExchangeRates
+	expires time.Time
+}
+
+// Fetch of a single base rates table shared by all concurrent requests for it
+type flight struct {
+	done  chan struct{}
+	rates *ExchangeRates
+	err   error
+}
+
+// Context carrying values of its parent, but not its cancellation nor deadline
+type detachedContext struct {
+	context.Context
+}
+
+func (d detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (d detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detachedContext) Err() error {
+	return nil
+}
+
+// CachingProvider wraps any provider and caches base rates tables it returns for a configured
+// time to live. Concurrent requests for a table that is not cached yet are coalesced into a single
+// upstream request, which is not abandoned when the request that started it is. Background
+// refreshes replace cached tables with fetched ones. Rates of date ranges are not cached.
+// Implements ConverterProvider and TimeSeriesProvider interfaces.
+type CachingProvider struct {
+	provider ConverterProvider
+	ttl      time.Duration
+
+	mu      *sync.Mutex
+	entries map[string]cacheEntry
+	flights map[string]*flight
+
+	// Returns current time. Replaced in tests.
+	now func() time.Time
+}
+
+// Name returns name of the wrapped provider
+func (c CachingProvider) Name() string {
+	return c.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (c CachingProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := c.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of base currency from cache. Whole base rates table is fetched
+// from wrapped provider if it is not cached yet or has expired.
+func (c CachingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	rates, status, err := c.getTable(ctx, base, date)
+	if err != nil {
+		return nil, err
+	}
+
+	r := *rates
+	r.Cache = status
+	r.Rates = selectRates(rates.Rates, base, symbols)
+	return &r, nil
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+func (c CachingProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	return TimeSeries(ctx, c.provider, base, symbols, start, end)
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (c CachingProvider) Capabilities() Capabilities {
+	return c.provider.Capabilities()
+}
+
+// Returns base rates table and information whether it was served from cache
+func (c CachingProvider) getTable(ctx context.Context, base string,
+	date time.Time) (*ExchangeRates, string, error) {
+
+	key := c.key(base, date)
+
+	c.mu.Lock()
+	_, refresh := ctx.Value(refreshKey{}).(bool)
+	if entry, exists := c.entries[key]; exists && !refresh && c.now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.rates, CacheHit, nil
+	}
+
+	// Requests that joined a pending fetch wait for upstream just like the one that started it
+	if f, exists := c.flights[key]; exists {
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.rates, CacheMiss, f.err
+		case <-ctx.Done():
+			return nil, CacheMiss, ctx.Err()
+		}
+	}
+
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	go c.fetch(ctx, key, base, date, f)
+
+	select {
+	case <-f.done:
+		return f.rates, CacheMiss, f.err
+	case <-ctx.Done():
+		return nil, CacheMiss, ctx.Err()
+	}
+}
+
+// Fetches base rates table shared by all requests that joined given flight and caches it. Fetch
+// is detached from cancellation of the request that started it, so that other requests waiting
+// for it are not failed when that request is abandoned.
+func (c CachingProvider) fetch(ctx context.Context, key, base string, date time.Time,
+	f *flight) {
+
+	fetchCtx, cancel := context.WithTimeout(detachedContext{ctx}, maxFetchDuration)
+	defer cancel()
+
+	f.rates, f.err = c.provider.Rates(fetchCtx, base, nil, date)
+
+	c.mu.Lock()
+	delete(c.flights, key)
+	if f.err == nil {
+		c.evictExpired()
+		c.entries[key] = cacheEntry{rates: f.rates, expires: c.now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	close(f.done)
+
+	if f.err != nil {
+		log.Printf("Could not fetch %s rates of %s provider: %s", base, c.Name(), f.err)
+	}
+}
+
+// Removes expired entries. Has to be called with mutex held.
+func (c CachingProvider) evictExpired() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Returns cache key of base rates table
+func (c CachingProvider) key(base string, date time.Time) string {
+	day := cacheKeyLatest
+	if !date.IsZero() {
+		day = date.Format(DateFormat)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", c.provider.Name(), base, day)
+}
+
+// NewCachingProvider returns provider caching base rates tables of given provider for given time
+func NewCachingProvider(provider ConverterProvider, ttl time.Duration) CachingProvider {
+	return CachingProvider{
+		provider: provider,
+		ttl:      ttl,
+		mu:       new(sync.Mutex),
+		entries:  make(map[string]cacheEntry),
+		flights:  make(map[string]*flight),
+		now:      time.Now,
+	}
+}
@@ -0,0 +1,158 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Provider blocking every rates request until released
+type blockingProvider struct {
+	dailyProvider
+	release chan struct{}
+	mu      *sync.Mutex
+}
+
+func (b blockingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dailyProvider.Rates(ctx, base, symbols, date)
+}
+
+func TestCachingProviderRates(t *testing.T) {
+	calls := new(int)
+	provider := NewCachingProvider(dailyProvider{calls}, time.Hour)
+	now := time.Date(2016, 10, 31, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+	date, _ := ParseDate("2016-10-28")
+
+	cases := []struct {
+		base           string
+		date           time.Time
+		after          time.Duration
+		expectedStatus string
+		expectedCalls  int
+	}{
+		{"PLN", date, 0, CacheMiss, 1},
+		{"PLN", date, time.Minute, CacheHit, 1},
+		{"EUR", date, 0, CacheMiss, 2},
+		{"PLN", time.Time{}, 0, CacheMiss, 3},
+		{"PLN", date, time.Hour, CacheMiss, 4},
+		{"PLN", date, 0, CacheHit, 4},
+	}
+
+	for _, c := range cases {
+		now = now.Add(c.after)
+		rates, err := provider.Rates(context.Background(), c.base, []string{"USD"}, c.date)
+		if err != nil {
+			t.Errorf("CachingProvider.Rates(%s, %s) returned error: %v", c.base, c.date, err)
+			continue
+		}
+
+		if rates.Cache != c.expectedStatus || *calls != c.expectedCalls {
+			t.Errorf("CachingProvider.Rates(%s, %s) == \ngot: %s (%d calls), \nexpected %s "+
+				"(%d calls)", c.base, c.date, rates.Cache, *calls, c.expectedStatus,
+				c.expectedCalls)
+		}
+	}
+}
+
+func TestCachingProviderAbandonedFetch(t *testing.T) {
+	calls := new(int)
+	upstream := blockingProvider{dailyProvider{calls}, make(chan struct{}), new(sync.Mutex)}
+	provider := NewCachingProvider(upstream, time.Hour)
+	date, _ := ParseDate("2016-10-28")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, err := provider.Rates(ctx, "PLN", nil, date)
+		leader <- err
+	}()
+
+	// Give the leader a chance to start the fetch before the second request joins it
+	time.Sleep(50 * time.Millisecond)
+	waiter := make(chan error)
+	go func() {
+		_, err := provider.Rates(context.Background(), "PLN", nil, date)
+		waiter <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-leader; err != context.Canceled {
+		t.Errorf("CachingProvider.Rates() of abandoned request == \ngot: %v, \nexpected %v", err,
+			context.Canceled)
+	}
+
+	close(upstream.release)
+	if err := <-waiter; err != nil || *calls != 1 {
+		t.Errorf("CachingProvider.Rates() of waiting request == \ngot: %v (%d calls), "+
+			"\nexpected no error (1 call)", err, *calls)
+	}
+}
+
+func TestCachingProviderCoalescing(t *testing.T) {
+	calls := new(int)
+	upstream := blockingProvider{dailyProvider{calls}, make(chan struct{}), new(sync.Mutex)}
+	provider := NewCachingProvider(upstream, time.Hour)
+	date, _ := ParseDate("2016-10-28")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rates, err := provider.Rates(context.Background(), "PLN", nil, date)
+			if err != nil {
+				t.Errorf("CachingProvider.Rates() returned error: %v", err)
+			} else if rates.Cache != CacheMiss {
+				t.Errorf("CachingProvider.Rates() == \ngot: %s, \nexpected %s", rates.Cache,
+					CacheMiss)
+			}
+		}()
+	}
+
+	// Give all requests a chance to join the pending fetch before it is released
+	time.Sleep(50 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if *calls != 1 {
+		t.Errorf("CachingProvider.Rates() called upstream %d times, expected 1", *calls)
+	}
+}
+
+func TestCachingProviderMetadata(t *testing.T) {
+	calls := new(int)
+	provider := NewCachingProvider(NewFallbackProvider([]ConverterProvider{
+		failingProvider{name: "a", err: errors.New("Connection refused.")},
+		dailyProvider{calls},
+	}), time.Hour)
+
+	for _, expected := range []string{CacheMiss, CacheHit} {
+		rates, err := provider.Rates(context.Background(), "PLN", []string{"USD"}, time.Time{})
+		if err != nil || rates.Cache != expected || rates.Provider != "daily" ||
+			len(rates.Failures) != 1 || len(rates.Rates) != 1 {
+			t.Errorf("CachingProvider.Rates(PLN) == \ngot: %v, %v, \nexpected %s rates of daily "+
+				"provider with 1 failure", rates, err, expected)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import "sort"
+
+// Capabilities describes which exchange rates provider is able to serve
+type Capabilities struct {
+	// Bases - base currencies for which rates can be served
+	Bases []string `json:"bases" xml:"bases>currency"`
+	// Targets - currencies to which amounts can be converted
+	Targets []string `json:"targets" xml:"targets>currency"`
+	// Historical - whether rates published on past dates can be served
+	Historical bool `json:"historical" xml:"historical"`
+	// TimeSeries - whether rates of a date range are fetched at once rather than day by day
+	TimeSeries bool `json:"timeSeries" xml:"timeSeries"`
+}
+
+// Capabilities of a provider serving all rates of any of given providers
+func mergeCapabilities(capabilities ...Capabilities) Capabilities {
+	bases, targets := make(map[string]bool), make(map[string]bool)
+	historical := false
+	for _, c := range capabilities {
+		addCurrencies(bases, c.Bases)
+		addCurrencies(targets, c.Targets)
+		historical = historical || c.Historical
+	}
+
+	return Capabilities{Bases: sortedCurrencies(bases), Targets: sortedCurrencies(targets),
+		Historical: historical}
+}
+
+func addCurrencies(set map[string]bool, currencies []string) {
+	for _, currency := range currencies {
+		set[currency] = true
+	}
+}
+
+func sortedCurrencies(set map[string]bool) []string {
+	currencies := make([]string, 0, len(set))
+	for currency := range set {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Strings(currencies)
+	return currencies
+}
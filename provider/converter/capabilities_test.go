@@ -0,0 +1,65 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeCapabilities(t *testing.T) {
+	cases := []struct {
+		capabilities []Capabilities
+		expected     Capabilities
+	}{
+		{
+			[]Capabilities{},
+			Capabilities{Bases: []string{}, Targets: []string{}},
+		},
+		{
+			[]Capabilities{
+				{Bases: []string{"PLN", "EUR"}, Targets: []string{"USD"}},
+				{Bases: []string{"EUR"}, Targets: []string{"USD", "GBP"}, Historical: true},
+			},
+			Capabilities{Bases: []string{"EUR", "PLN"}, Targets: []string{"GBP", "USD"},
+				Historical: true},
+		},
+	}
+
+	for _, c := range cases {
+		actual := mergeCapabilities(c.capabilities...)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("mergeCapabilities(%v) == \ngot: %v, \nexpected %v", c.capabilities, actual,
+				c.expected)
+		}
+	}
+}
+
+func TestLocalProviderCapabilities(t *testing.T) {
+	capabilities := NewLocalProvider(DefaultPivot).Capabilities()
+	if len(capabilities.Bases) != 32 || !capabilities.Historical ||
+		!reflect.DeepEqual(capabilities.Bases, capabilities.Targets) {
+		t.Errorf("LocalProvider.Capabilities() == \ngot: %v, \nexpected 32 historical bases "+
+			"equal to targets", capabilities)
+	}
+}
+
+func TestTriangulatingProviderCapabilities(t *testing.T) {
+	capabilities := NewTriangulatingProvider(dailyProvider{new(int)}, "EUR").Capabilities()
+	expected := Capabilities{Bases: []string{"EUR", "PLN", "USD"}, Targets: []string{"USD"},
+		Historical: true}
+	if !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("TriangulatingProvider.Capabilities() == \ngot: %v, \nexpected %v",
+			capabilities, expected)
+	}
+}
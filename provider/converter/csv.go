@@ -0,0 +1,355 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// DefaultCSVColumns - columns of rate files used when no mapping is given
+var DefaultCSVColumns = CSVColumns{Date: "date", Base: "base", Quote: "quote", Rate: "rate"}
+
+// CSVColumns maps header names of a rate file to rate fields
+type CSVColumns struct {
+	// Date - column of rates date (YYYY-MM-DD)
+	Date string `json:"date"`
+	// Base - column of base currency
+	Base string `json:"base"`
+	// Quote - column of quote currency
+	Quote string `json:"quote"`
+	// Rate - column of price of one unit of base currency in quote currency
+	Rate string `json:"rate"`
+	// Inverse - optional column of price of one unit of quote currency in base currency
+	Inverse string `json:"inverse"`
+	// Delimiter - field delimiter, comma if empty
+	Delimiter string `json:"delimiter"`
+}
+
+// Rates of a single day by their base currency
+type csvDay map[string]Rates
+
+// CSVProvider serves exchange rates maintained in CSV files. Every row of a file holds rate of
+// a currency pair of a day. Rates of a pair in opposite direction are derived when they are not
+// given. Newest rates published on or before requested date are served. Implements
+// ConverterProvider interface.
+type CSVProvider struct {
+	days  map[string]csvDay
+	dates []string
+}
+
+// Name returns name of this provider
+func (c CSVProvider) Name() string {
+	return CSV
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (c CSVProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := c.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns rates of given base currency published on or before given date, the newest ones
+// if date is zero
+func (c CSVProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	base = strings.ToUpper(base)
+	day := ""
+	for _, published := range c.dates {
+		if date.IsZero() || published <= date.Format(DateFormat) {
+			day = published
+		}
+	}
+
+	if len(day) == 0 {
+		log.Printf("CSV provider has no rates for %s.", date.Format(DateFormat))
+		return nil, common.NewError(common.ErrRateNotFound, "CSV provider has no rates for %s.",
+			date.Format(DateFormat))
+	}
+
+	rates, exists := c.days[day][base]
+	if !exists {
+		log.Printf("Currency %s not supported by CSV provider.", base)
+		return nil, common.NewError(common.ErrUnsupportedCurrency,
+			"Currency %s not supported by CSV provider.", base)
+	}
+
+	return &ExchangeRates{Base: base, Date: day, Rates: selectRates(rates, base, symbols)}, nil
+}
+
+// Capabilities returns currencies of all rate files
+func (c CSVProvider) Capabilities() Capabilities {
+	currencies := make(map[string]bool)
+	for _, day := range c.days {
+		for base, rates := range day {
+			currencies[base] = true
+			for quote := range rates {
+				currencies[quote] = true
+			}
+		}
+	}
+
+	sorted := sortedCurrencies(currencies)
+	return Capabilities{Bases: sorted, Targets: sorted, Historical: true}
+}
+
+// Reads rates of given file into given days. Duplicated pairs of a day are reported as errors.
+func readCSVFile(path string, columns CSVColumns, days map[string]csvDay,
+	published map[string]bool) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	if len(columns.Delimiter) > 0 {
+		reader.Comma = []rune(columns.Delimiter)[0]
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: Could not read header: %s", path, err)
+	}
+
+	indexes, err := columnIndexes(header, columns)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+
+		if err := readCSVRecord(record, indexes, days, published); err != nil {
+			return fmt.Errorf("%s:%d: %s", path, line, err)
+		}
+	}
+}
+
+// Returns indexes of mapped columns in given header: date, base, quote, rate and inverse, which is
+// -1 if not mapped
+func columnIndexes(header []string, columns CSVColumns) ([]int, error) {
+	names := []string{columns.Date, columns.Base, columns.Quote, columns.Rate, columns.Inverse}
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		indexes[i] = -1
+		for j, column := range header {
+			if len(name) > 0 && strings.EqualFold(strings.TrimSpace(column), name) {
+				indexes[i] = j
+			}
+		}
+
+		if indexes[i] < 0 && i < 3 {
+			return nil, fmt.Errorf("Missing column '%s'.", name)
+		}
+	}
+
+	if indexes[3] < 0 && indexes[4] < 0 {
+		return nil, fmt.Errorf("Missing column '%s' or '%s'.", columns.Rate, columns.Inverse)
+	}
+
+	return indexes, nil
+}
+
+// Reads rates of a single record. Pairs that were published are marked in given set.
+func readCSVRecord(record []string, indexes []int, days map[string]csvDay,
+	published map[string]bool) error {
+
+	field := func(i int) string {
+		if indexes[i] < 0 || indexes[i] >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[indexes[i]])
+	}
+
+	date, err := ParseDate(field(0))
+	if err != nil {
+		return err
+	}
+
+	base, err := common.NormalizeCurrency(field(1))
+	if err != nil {
+		return err
+	}
+
+	quote, err := common.NormalizeCurrency(field(2))
+	if err != nil {
+		return err
+	}
+
+	rate, err := parseCSVRate(field(3))
+	if err != nil {
+		return err
+	}
+
+	inverse, err := parseCSVRate(field(4))
+	if err != nil {
+		return err
+	}
+
+	if rate.IsZero() && inverse.IsZero() {
+		return fmt.Errorf("No rate of %s/%s given.", base, quote)
+	}
+
+	day := date.Format(DateFormat)
+	if days[day] == nil {
+		days[day] = make(csvDay)
+	}
+
+	pairs := []struct {
+		from, to string
+		rate     common.Decimal
+	}{{base, quote, rate}, {quote, base, inverse}}
+	for _, pair := range pairs {
+		if pair.rate.IsZero() {
+			continue
+		}
+
+		key := day + "/" + pair.from + "/" + pair.to
+		if published[key] {
+			return fmt.Errorf("Duplicated rate of %s/%s for %s.", pair.from, pair.to, day)
+		}
+
+		published[key] = true
+		if days[day][pair.from] == nil {
+			days[day][pair.from] = make(Rates)
+		}
+
+		days[day][pair.from][pair.to] = pair.rate
+	}
+
+	return nil
+}
+
+// Parses rate, which has to be positive. Empty rate is returned as zero.
+func parseCSVRate(value string) (common.Decimal, error) {
+	if len(value) == 0 {
+		return common.Decimal{}, nil
+	}
+
+	rate, err := common.ParseDecimal(value)
+	if err != nil {
+		return common.Decimal{}, err
+	}
+
+	if rate.Sign() <= 0 {
+		return common.Decimal{}, fmt.Errorf("Rate %s has to be positive.", value)
+	}
+
+	return rate, nil
+}
+
+// Checks that every day publishes rates of the same currency pairs, in either direction, and
+// derives rates in opposite direction of pairs that were not published
+func completeCSVDays(days map[string]csvDay, dates []string) error {
+	pairs := make(map[string][]string)
+	for _, day := range days {
+		for base, rates := range day {
+			for quote := range rates {
+				pairs[base+"/"+quote] = []string{base, quote}
+			}
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, date := range dates {
+		day := days[date]
+		for name, pair := range pairs {
+			_, direct := day[pair[0]][pair[1]]
+			_, opposite := day[pair[1]][pair[0]]
+			if !direct && !opposite {
+				missing = append(missing, fmt.Sprintf("%s for %s", name, date))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("Missing rates of %s.", strings.Join(missing, ", "))
+	}
+
+	for _, date := range dates {
+		day := days[date]
+		for _, pair := range pairs {
+			if _, exists := day[pair[1]][pair[0]]; exists {
+				continue
+			}
+
+			if day[pair[1]] == nil {
+				day[pair[1]] = make(Rates)
+			}
+
+			day[pair[1]][pair[0]] = common.NewDecimal(1, 0).Quo(day[pair[0]][pair[1]],
+				crossRateScale, common.HalfEven)
+		}
+	}
+
+	return nil
+}
+
+// LoadCSVProvider returns provider serving rates of given CSV files read with given column
+// mapping. Files have to contain a header row. Every day has to publish rates of the same currency
+// pairs and every pair can be published only once per day.
+func LoadCSVProvider(paths []string, columns CSVColumns) (CSVProvider, error) {
+	if len(paths) == 0 {
+		return CSVProvider{}, fmt.Errorf("No CSV rate files given.")
+	}
+
+	days := make(map[string]csvDay)
+	published := make(map[string]bool)
+	for _, path := range paths {
+		if err := readCSVFile(path, columns, days, published); err != nil {
+			return CSVProvider{}, err
+		}
+	}
+
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+
+	sort.Strings(dates)
+	if err := completeCSVDays(days, dates); err != nil {
+		return CSVProvider{}, err
+	}
+
+	log.Printf("Loaded CSV rates of %d days from %s", len(dates), strings.Join(paths, ", "))
+	return CSVProvider{days: days, dates: dates}, nil
+}
@@ -0,0 +1,151 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Writes given rate files to a temporary directory and returns their paths
+func writeCSVFiles(t *testing.T, files ...string) (string, []string) {
+	dir, err := ioutil.TempDir("", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for i, content := range files {
+		path := filepath.Join(dir, string('a'+rune(i))+".csv")
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return dir, paths
+}
+
+func TestCSVProviderRates(t *testing.T) {
+	dir, paths := writeCSVFiles(t,
+		"date,base,quote,rate\n2020-01-02,PLN,EUR,0.25\n2020-01-03,PLN,EUR,0.2\n",
+		"date,base,quote,rate\n2020-01-02,EUR,GBP,0.8\n2020-01-03,gbp,eur,1.25\n")
+	defer os.RemoveAll(dir)
+
+	provider, err := LoadCSVProvider(paths, DefaultCSVColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		base         string
+		symbol       string
+		date         time.Time
+		expectedDate string
+		expectedRate string
+		expectedCode common.ErrorCode
+	}{
+		{"PLN", "EUR", time.Time{}, "2020-01-03", "0.2", ""},
+		{"PLN", "EUR", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "2020-01-02", "0.25", ""},
+		{"PLN", "EUR", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), "2020-01-03", "0.2", ""},
+		{"EUR", "PLN", time.Time{}, "2020-01-03", "5", ""},
+		{"EUR", "GBP", time.Time{}, "2020-01-03", "0.8", ""},
+		{"GBP", "EUR", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "2020-01-02", "1.25", ""},
+		{"PLN", "EUR", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "", "",
+			common.ErrRateNotFound},
+		{"USD", "EUR", time.Time{}, "", "", common.ErrUnsupportedCurrency},
+	}
+
+	for _, c := range cases {
+		rates, err := provider.Rates(context.Background(), c.base, []string{c.symbol}, c.date)
+		if len(c.expectedCode) > 0 {
+			if common.AsError(err).Code != c.expectedCode {
+				t.Errorf("Rates(%s, %s, %v) == \ngot: %v, \nexpected %s", c.base, c.symbol,
+					c.date, err, c.expectedCode)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rate := rates.Rates[c.symbol]
+		if rates.Date != c.expectedDate || rate.Cmp(common.MustParseDecimal(c.expectedRate)) != 0 {
+			t.Errorf("Rates(%s, %s, %v) == \ngot: %s %s, \nexpected %s %s", c.base, c.symbol,
+				c.date, rates.Date, rate, c.expectedDate, c.expectedRate)
+		}
+	}
+}
+
+func TestCSVProviderColumns(t *testing.T) {
+	dir, paths := writeCSVFiles(t,
+		"Day;From;To;Bid;Ask\n2020-01-02;PLN;EUR;0.25;4.1\n")
+	defer os.RemoveAll(dir)
+
+	columns := CSVColumns{Date: "day", Base: "from", Quote: "to", Rate: "bid", Inverse: "ask",
+		Delimiter: ";"}
+	provider, err := LoadCSVProvider(paths, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := provider.Rates(context.Background(), "EUR", nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rate := rates.Rates["PLN"]; rate.String() != "4.1" {
+		t.Errorf("Rates(EUR) == \ngot: %s, \nexpected %s", rate, "4.1")
+	}
+
+	capabilities := provider.Capabilities()
+	if strings.Join(capabilities.Bases, ",") != "EUR,PLN" || !capabilities.Historical {
+		t.Errorf("Capabilities() == \ngot: %v, \nexpected %v", capabilities, "EUR,PLN")
+	}
+}
+
+func TestLoadCSVProviderErrors(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected string
+	}{
+		{"date,base,rate\n", "Missing column 'quote'."},
+		{"date,base,quote,rate\n2020-01-02,PLN,EUR,abc\n", "a.csv:2:"},
+		{"date,base,quote,rate\n2020-01-02,PLN,EUR,-1\n", "Rate -1 has to be positive."},
+		{"date,base,quote,rate\n2020-13-02,PLN,EUR,1\n", "a.csv:2:"},
+		{"date,base,quote,rate\n2020-01-02,PLN,EUR,0.25\n2020-01-02,PLN,EUR,0.26\n",
+			"a.csv:3: Duplicated rate of PLN/EUR for 2020-01-02."},
+		{"date,base,quote,rate\n2020-01-02,PLN,EUR,0.25\n2020-01-03,PLN,GBP,0.2\n",
+			"Missing rates of PLN/EUR for 2020-01-03, PLN/GBP for 2020-01-02."},
+	}
+
+	for _, c := range cases {
+		dir, paths := writeCSVFiles(t, c.content)
+		_, err := LoadCSVProvider(paths, DefaultCSVColumns)
+		os.RemoveAll(dir)
+
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("LoadCSVProvider(%q) == \ngot: %v, \nexpected %s", c.content, err, c.expected)
+		}
+	}
+}
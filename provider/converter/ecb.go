@@ -0,0 +1,335 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// ECB feed with euro foreign exchange reference rates of the last business day
+const ecbDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECB feed with all euro foreign exchange reference rates published since 1999
+const ecbHistoryURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml"
+
+// ECB publishes rates of euro only, rates of other currencies are derived through it
+const ecbBase = "EUR"
+
+// How long parsed history feed is reused. Feed is updated once a business day and
+// weighs several megabytes, so it is not fetched for every historical date.
+const ecbHistoryTTL = time.Hour
+
+// Currencies quoted in the ECB euro foreign exchange reference rates. Currencies replaced by euro
+// are left out by the currency registry, so that only publication changes need to be added here.
+var ecbQuoted = []string{"AUD", "BGN", "BRL", "CAD", "CHF", "CNY", "CZK", "DKK", "EUR", "GBP",
+	"HKD", "HUF", "IDR", "ILS", "INR", "ISK", "JPY", "KRW", "MXN", "MYR", "NOK", "NZD", "PHP",
+	"PLN", "RON", "SEK", "SGD", "THB", "TRY", "USD", "ZAR"}
+
+// Active currencies of the ECB euro foreign exchange reference rates, including euro itself. Used
+// until a feed is fetched.
+var ecbCurrencies = activeCurrencies(ecbQuoted)
+
+// Returns given currencies which are active according to the currency registry
+func activeCurrencies(codes []string) []string {
+	result := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if currency, exists := common.LookupCurrency(code); exists && currency.Active {
+			result = append(result, code)
+		}
+	}
+
+	return result
+}
+
+// ECBEnvelope is a structure of ECB euro foreign exchange reference rates feeds. Rates are nested
+// in three levels of Cube elements: root, days and rates of a day.
+type ECBEnvelope struct {
+	// Days - rates published on every day of the feed
+	Days []ECBDay `xml:"Cube>Cube"`
+}
+
+// ECBDay holds rates published by ECB on a single day
+type ECBDay struct {
+	// Time - date on which rates were published
+	Time string `xml:"time,attr"`
+	// Rates - euro exchange rates
+	Rates []ECBRate `xml:"Cube"`
+}
+
+// ECBRate is a single euro exchange rate published by ECB
+type ECBRate struct {
+	// Currency - target currency
+	Currency string `xml:"currency,attr"`
+	// Rate - amount of target currency for 1 euro
+	Rate common.Decimal `xml:"rate,attr"`
+}
+
+// Sorts ECB days by date
+type byTime []ECBDay
+
+func (b byTime) Len() int           { return len(b) }
+func (b byTime) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byTime) Less(i, j int) bool { return b[i].Time < b[j].Time }
+
+// Parsed history feed shared by all copies of ECB provider
+type ecbHistory struct {
+	mu      sync.Mutex
+	days    []ECBDay
+	fetched time.Time
+	load    *ecbHistoryLoad
+}
+
+// Currencies of the newest day fetched from any ECB feed, shared by all copies of ECB provider
+type ecbPublished struct {
+	mu         sync.Mutex
+	date       string
+	currencies []string
+}
+
+// Fetch of history feed shared by all concurrent requests for it
+type ecbHistoryLoad struct {
+	done chan struct{}
+	days []ECBDay
+	err  error
+}
+
+// ECBProvider represents provider used to convert exchange rates based on euro foreign exchange
+// reference rates published by European Central Bank. History feed is fetched at most once per
+// ecbHistoryTTL. Supported currencies are taken from the newest fetched day. Implements
+// ConverterProvider and TimeSeriesProvider interfaces.
+type ECBProvider struct {
+	dailyURL   string
+	historyURL string
+
+	// Client used for requests to ECB feeds, common.DefaultHTTPClient if nil
+	client *common.HTTPClient
+
+	history   *ecbHistory
+	published *ecbPublished
+
+	// Returns current time. Replaced in tests.
+	now func() time.Time
+}
+
+// Name returns name of this provider
+func (e ECBProvider) Name() string {
+	return ECB
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (e ECBProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	log.Printf("ECB provider - converting %s %s", request.Amount, request.Currency)
+
+	rates, err := e.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns ECB exchange rates rebased to given base currency. Latest rates are taken from
+// daily feed if date is zero, otherwise rates of the last business day up to given date are taken
+// from history feed.
+func (e ECBProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	var days []ECBDay
+	var err error
+	if date.IsZero() {
+		days, err = e.getDays(ctx, e.dailyURL)
+	} else {
+		days, err = e.getHistory(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(days) - 1; i >= 0; i-- {
+		if date.IsZero() || days[i].Time <= date.Format(DateFormat) {
+			return e.rebase(days[i], base, symbols)
+		}
+	}
+
+	return nil, common.NewError(common.ErrRateNotFound, "ECB provider has no rates for %s.",
+		date.Format(DateFormat))
+}
+
+// Capabilities returns currencies of the newest fetched ECB euro reference rates, or of the known
+// ones if no feed was fetched yet. Every one of them can be used as a base, as rates are derived
+// through euro.
+func (e ECBProvider) Capabilities() Capabilities {
+	currencies := ecbCurrencies
+	e.published.mu.Lock()
+	if e.published.currencies != nil {
+		currencies = e.published.currencies
+	}
+	e.published.mu.Unlock()
+
+	return Capabilities{Bases: currencies, Targets: currencies, Historical: true,
+		TimeSeries: true}
+}
+
+// TimeSeries returns ECB exchange rates of every day between start and end dates rebased to given
+// base currency. Whole range is taken from a single request to history feed.
+func (e ECBProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	days, err := e.getHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]*ExchangeRates, 0)
+	for _, day := range days {
+		if day.Time < start.Format(DateFormat) || day.Time > end.Format(DateFormat) {
+			continue
+		}
+
+		rates, err := e.rebase(day, base, symbols)
+		if err != nil {
+			return nil, err
+		}
+
+		series = append(series, rates)
+	}
+
+	return series, nil
+}
+
+// Returns days of history feed ordered by date. Feed is fetched again once ecbHistoryTTL expires.
+// Concurrent requests wait for a single fetch, each of them only until its context is done.
+func (e ECBProvider) getHistory(ctx context.Context) ([]ECBDay, error) {
+	e.history.mu.Lock()
+	if e.history.days != nil && e.now().Sub(e.history.fetched) < ecbHistoryTTL {
+		days := e.history.days
+		e.history.mu.Unlock()
+		return days, nil
+	}
+
+	load := e.history.load
+	if load == nil {
+		load = &ecbHistoryLoad{done: make(chan struct{})}
+		e.history.load = load
+		go e.loadHistory(ctx, load)
+	}
+	e.history.mu.Unlock()
+
+	select {
+	case <-load.done:
+		return load.days, load.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Fetches history feed shared by all requests waiting for given load and keeps it. Fetch is
+// detached from cancellation of the request that started it.
+func (e ECBProvider) loadHistory(ctx context.Context, load *ecbHistoryLoad) {
+	fetchCtx, cancel := context.WithTimeout(detachedContext{ctx}, maxFetchDuration)
+	defer cancel()
+
+	load.days, load.err = e.getDays(fetchCtx, e.historyURL)
+
+	e.history.mu.Lock()
+	e.history.load = nil
+	if load.err == nil {
+		e.history.days, e.history.fetched = load.days, e.now()
+	}
+	e.history.mu.Unlock()
+	close(load.done)
+}
+
+// Fetches feed from given url and returns its days ordered by date
+func (e ECBProvider) getDays(ctx context.Context, url string) ([]ECBDay, error) {
+	envelope := new(ECBEnvelope)
+	if err := httpClient(e.client).GetXml(ctx, url, envelope); err != nil {
+		log.Printf("Error during request to ECB: %s", err)
+		return nil, err
+	}
+
+	if len(envelope.Days) == 0 {
+		log.Printf("ECB feed %s does not contain any rates", url)
+		return nil, common.NewError(common.ErrUpstreamUnavailable,
+			"ECB feed does not contain any rates.")
+	}
+
+	sort.Sort(byTime(envelope.Days))
+	e.publish(envelope.Days[len(envelope.Days)-1])
+	return envelope.Days, nil
+}
+
+// Keeps currencies of given day if it is not older than the newest day fetched so far
+func (e ECBProvider) publish(day ECBDay) {
+	currencies := []string{ecbBase}
+	for _, rate := range day.Rates {
+		currencies = append(currencies, strings.ToUpper(rate.Currency))
+	}
+	sort.Strings(currencies)
+
+	e.published.mu.Lock()
+	if day.Time >= e.published.date {
+		e.published.date, e.published.currencies = day.Time, currencies
+	}
+	e.published.mu.Unlock()
+}
+
+// Converts euro rates of given day to rates of given base currency
+func (e ECBProvider) rebase(day ECBDay, base string, symbols []string) (*ExchangeRates, error) {
+	rates := make(Rates, len(day.Rates))
+	for _, rate := range day.Rates {
+		rates[strings.ToUpper(rate.Currency)] = rate.Rate
+	}
+
+	base = strings.ToUpper(base)
+	if _, exists := rates[base]; !exists && base != ecbBase {
+		log.Printf("Currency %s not supported by ECB provider.", base)
+		return nil, common.NewError(common.ErrUnsupportedCurrency,
+			"Currency %s not supported by ECB provider.", base)
+	}
+
+	return Triangulate(&ExchangeRates{Base: ecbBase, Date: day.Time, Rates: rates}, base, symbols)
+}
+
+// NewECBProvider returns initialized ECB provider object using official ECB feeds
+func NewECBProvider() ECBProvider {
+	return NewECBProviderWithURLs(ecbDailyURL, ecbHistoryURL, nil)
+}
+
+// NewECBProviderWithURLs returns ECB provider object using feeds served under given urls. Official
+// feeds are used in place of empty urls. Given headers are sent with every request.
+func NewECBProviderWithURLs(dailyURL, historyURL string,
+	headers map[string]string) ECBProvider {
+
+	if len(dailyURL) == 0 {
+		dailyURL = ecbDailyURL
+	}
+
+	if len(historyURL) == 0 {
+		historyURL = ecbHistoryURL
+	}
+
+	return ECBProvider{dailyURL: dailyURL, historyURL: historyURL, client: newHTTPClient(headers),
+		history: new(ecbHistory), published: new(ecbPublished), now: time.Now}
+}
@@ -0,0 +1,263 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const ecbDailyFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01"
+	xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2016-10-31">
+			<Cube currency="USD" rate="1.0946"/>
+			<Cube currency="PLN" rate="4.3278"/>
+			<Cube currency="JPY" rate="115.01"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbHistoryFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01"
+	xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2016-10-31">
+			<Cube currency="USD" rate="1.0946"/>
+			<Cube currency="PLN" rate="4.3278"/>
+		</Cube>
+		<Cube time="2016-10-28">
+			<Cube currency="USD" rate="1.0963"/>
+			<Cube currency="PLN" rate="4.3195"/>
+		</Cube>
+		<Cube time="2016-10-27">
+			<Cube currency="USD" rate="1.0916"/>
+			<Cube currency="PLN" rate="4.3127"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+// Returns ECB provider using feeds served by a local fixture server
+func newECBFixtureProvider() (ECBProvider, *httptest.Server) {
+	provider, server, _ := newCountingECBFixtureProvider()
+	return provider, server
+}
+
+// Returns ECB provider using feeds served by a local fixture server counting history requests
+func newCountingECBFixtureProvider() (ECBProvider, *httptest.Server, *int32) {
+	historyRequests := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/daily.xml":
+			fmt.Fprint(w, ecbDailyFixture)
+		case "/hist.xml":
+			atomic.AddInt32(historyRequests, 1)
+			fmt.Fprint(w, ecbHistoryFixture)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return NewECBProviderWithURLs(server.URL+"/daily.xml", server.URL+"/hist.xml", nil),
+		server, historyRequests
+}
+
+func TestECBProviderRates(t *testing.T) {
+	provider, server := newECBFixtureProvider()
+	defer server.Close()
+
+	cases := []struct {
+		base          string
+		symbols       []string
+		date          string
+		expectedDate  string
+		expectedRates map[string]string
+	}{
+		{"EUR", nil, "", "2016-10-31",
+			map[string]string{"USD": "1.0946", "PLN": "4.3278", "JPY": "115.01"}},
+		{"EUR", []string{"USD"}, "2016-10-30", "2016-10-28", map[string]string{"USD": "1.0963"}},
+		{"pln", []string{"USD", "EUR"}, "2016-10-27", "2016-10-27",
+			map[string]string{"USD": "0.253112899112", "EUR": "0.231873304426"}},
+		{"USD", []string{"JPY"}, "", "2016-10-31", map[string]string{"JPY": "105.070345331628"}},
+	}
+
+	for _, c := range cases {
+		var date time.Time
+		if c.date != "" {
+			date, _ = ParseDate(c.date)
+		}
+
+		rates, err := provider.Rates(context.Background(), c.base, c.symbols, date)
+		if err != nil {
+			t.Errorf("ECBProvider.Rates(%s, %v, %s) returned error: %v", c.base, c.symbols,
+				c.date, err)
+			continue
+		}
+
+		got := ratesToStrings(ConvertedRates(rates.Rates))
+		if rates.Date != c.expectedDate || !reflect.DeepEqual(got, c.expectedRates) {
+			t.Errorf("ECBProvider.Rates(%s, %v, %s) == \ngot: %s %v, \nexpected %s %v", c.base,
+				c.symbols, c.date, rates.Date, got, c.expectedDate, c.expectedRates)
+		}
+	}
+}
+
+func TestECBProviderHistoryReuse(t *testing.T) {
+	provider, server, historyRequests := newCountingECBFixtureProvider()
+	defer server.Close()
+
+	now := time.Date(2016, 11, 1, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+	start, _ := ParseDate("2016-10-27")
+	end, _ := ParseDate("2016-10-31")
+
+	cases := []struct {
+		after    time.Duration
+		expected int32
+	}{
+		{0, 1},
+		{time.Minute, 1},
+		{ecbHistoryTTL, 2},
+	}
+
+	for _, c := range cases {
+		now = now.Add(c.after)
+		if _, err := TimeSeries(context.Background(), NewNamedProvider(provider, "ecb"), "PLN",
+			[]string{"EUR"}, start, end); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := atomic.LoadInt32(historyRequests); actual != c.expected {
+			t.Errorf("TimeSeries() after %s == \ngot: %d history requests, \nexpected %d",
+				c.after, actual, c.expected)
+		}
+	}
+}
+
+func TestECBProviderHistoryCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, ecbHistoryFixture)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	provider := NewECBProviderWithURLs(server.URL, server.URL, nil)
+	date, _ := ParseDate("2016-10-31")
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := provider.Rates(ctx, "PLN", nil, date)
+		cancel()
+
+		if err != context.DeadlineExceeded {
+			t.Errorf("ECBProvider.Rates() of slow history feed == \ngot: %v, \nexpected %v", err,
+				context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestECBProviderRatesErrors(t *testing.T) {
+	provider, server := newECBFixtureProvider()
+	defer server.Close()
+
+	date, _ := ParseDate("2016-10-26")
+	if _, err := provider.Rates(context.Background(), "EUR", nil, date); err == nil {
+		t.Errorf("ECBProvider.Rates(EUR, 2016-10-26) expected error")
+	}
+
+	if _, err := provider.Rates(context.Background(), "KWD", nil, time.Time{}); err == nil {
+		t.Errorf("ECBProvider.Rates(KWD) expected error")
+	}
+
+	broken := NewECBProviderWithURLs(server.URL+"/missing.xml", server.URL+"/missing.xml",
+		nil)
+	if _, err := broken.Rates(context.Background(), "EUR", nil, time.Time{}); err == nil {
+		t.Errorf("ECBProvider.Rates(EUR) with missing feed expected error")
+	}
+}
+
+func TestECBProviderTimeSeries(t *testing.T) {
+	provider, server := newECBFixtureProvider()
+	defer server.Close()
+
+	start, _ := ParseDate("2016-10-28")
+	end, _ := ParseDate("2016-11-01")
+	series, err := TimeSeries(context.Background(), provider, "EUR", []string{"PLN"}, start, end)
+	if err != nil {
+		t.Fatalf("TimeSeries(ECBProvider) returned error: %v", err)
+	}
+
+	got := make([]string, 0)
+	for _, rates := range series {
+		got = append(got, rates.Date+"="+rates.Rates["PLN"].String())
+	}
+
+	expected := []string{"2016-10-28=4.3195", "2016-10-31=4.3278"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("TimeSeries(ECBProvider) == \ngot: %v, \nexpected %v", got, expected)
+	}
+}
+
+func TestECBProviderCapabilities(t *testing.T) {
+	provider, server := newECBFixtureProvider()
+	defer server.Close()
+
+	known := make(map[string]bool)
+	for _, currency := range provider.Capabilities().Bases {
+		known[currency] = true
+	}
+	if known["BGN"] || !known["ISK"] {
+		t.Errorf("ECBProvider.Capabilities() == \ngot: %v, \nexpected active ECB currencies",
+			provider.Capabilities().Bases)
+	}
+
+	cases := []struct {
+		date     string
+		expected []string
+	}{
+		{"2016-10-30", []string{"EUR", "PLN", "USD"}},
+		{"", []string{"EUR", "JPY", "PLN", "USD"}},
+		{"2016-10-28", []string{"EUR", "JPY", "PLN", "USD"}},
+	}
+
+	for _, c := range cases {
+		var date time.Time
+		if c.date != "" {
+			date, _ = ParseDate(c.date)
+		}
+
+		if _, err := provider.Rates(context.Background(), "EUR", nil, date); err != nil {
+			t.Errorf("ECBProvider.Rates(%s) returned error: %v", c.date, err)
+			continue
+		}
+
+		actual := provider.Capabilities().Bases
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("ECBProvider.Capabilities() after Rates(%s) == \ngot: %v, \nexpected %v",
+				c.date, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// ProviderFailure describes why a provider could not serve exchange rates
+type ProviderFailure struct {
+	// Provider - name of the provider that failed
+	Provider string `json:"provider" xml:"provider"`
+	// Code - kind of the failure
+	Code common.ErrorCode `json:"code" xml:"code"`
+	// Error - reason of the failure
+	Error string `json:"error" xml:"error"`
+}
+
+// ProviderFailures is a list of provider failures. Every failure is marshalled to XML as a separate
+// 'failure' element.
+type ProviderFailures []ProviderFailure
+
+// MarshalXML implements xml.Marshaler
+func (p ProviderFailures) MarshalXML(enc *xml.Encoder, startElem xml.StartElement) error {
+	failures := struct {
+		Failures []ProviderFailure `xml:"failure"`
+	}{p}
+
+	return enc.EncodeElement(failures, startElem)
+}
+
+// FallbackProvider asks an ordered list of providers for exchange rates and serves the first
+// successful answer. Providers are expected to give up on their own after their timeouts, see
+// TimeoutProvider. Implements ConverterProvider interface.
+type FallbackProvider struct {
+	providers []ConverterProvider
+}
+
+// Name returns name of this provider
+func (f FallbackProvider) Name() string {
+	return Fallback
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (f FallbackProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := f.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of the first provider that served them. Name of that provider
+// and failures of providers tried before are reported in returned rates. Next providers are not
+// tried once given context is done.
+func (f FallbackProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	failures := make(ProviderFailures, 0)
+	timeouts := 0
+	for _, provider := range f.providers {
+		rates, err := provider.Rates(ctx, base, symbols, date)
+		if err == nil {
+			result := *rates
+			result.Provider = provider.Name()
+			result.Failures = failures
+			return &result, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		log.Printf("Fallback provider - %s failed: %s", provider.Name(), err)
+		clientErr := common.AsError(err)
+		failures = append(failures, ProviderFailure{provider.Name(), clientErr.Code,
+			clientErr.Message})
+		if IsTimeout(err) {
+			timeouts++
+		}
+	}
+
+	return nil, &FallbackError{Base: base, Failures: failures,
+		timeout: timeouts > 0 && timeouts == len(failures)}
+}
+
+// Capabilities returns currencies served by any of the providers
+func (f FallbackProvider) Capabilities() Capabilities {
+	capabilities := make([]Capabilities, 0, len(f.providers))
+	for _, provider := range f.providers {
+		capabilities = append(capabilities, provider.Capabilities())
+	}
+
+	return mergeCapabilities(capabilities...)
+}
+
+// FallbackError is returned when none of the providers served rates
+type FallbackError struct {
+	// Base - currency whose rates were requested
+	Base string
+	// Failures - reasons why every provider failed
+	Failures ProviderFailures
+
+	timeout bool
+}
+
+// Error implements error interface
+func (f *FallbackError) Error() string {
+	return fmt.Sprintf("All providers failed to serve %s rates: %s", f.Base,
+		failuresString(f.Failures))
+}
+
+// Timeout returns true if all providers failed because of their timeouts
+func (f *FallbackError) Timeout() bool {
+	return f.timeout
+}
+
+// Describe returns error returned to clients. Failures of the same kind, i.e. a currency none of
+// the providers supports, are reported as that kind. Mixed failures are reported as unavailable
+// upstream.
+func (f *FallbackError) Describe() *common.Error {
+	code := common.ErrUpstreamUnavailable
+	if f.timeout {
+		code = common.ErrUpstreamTimeout
+	} else if len(f.Failures) > 0 {
+		code = f.Failures[0].Code
+		for _, failure := range f.Failures[1:] {
+			if failure.Code != code {
+				code = common.ErrUpstreamUnavailable
+				break
+			}
+		}
+	}
+
+	if code == common.ErrInternal {
+		code = common.ErrUpstreamUnavailable
+	}
+
+	return &common.Error{Code: code, Message: f.Error()}
+}
+
+func failuresString(failures ProviderFailures) string {
+	result := ""
+	for i, failure := range failures {
+		if i > 0 {
+			result += "; "
+		}
+
+		result += failure.Provider + ": " + failure.Error
+	}
+
+	return result
+}
+
+// NewFallbackProvider returns provider trying given providers in order
+func NewFallbackProvider(providers []ConverterProvider) FallbackProvider {
+	return FallbackProvider{providers: providers}
+}
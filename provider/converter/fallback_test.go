@@ -0,0 +1,175 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Provider failing every rates request with given error
+type failingProvider struct {
+	dailyProvider
+	name string
+	err  error
+}
+
+func (f failingProvider) Name() string {
+	return f.name
+}
+
+func (f failingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	return nil, f.err
+}
+
+// Provider answering after given delay unless its context is done earlier
+type slowProvider struct {
+	dailyProvider
+	delay time.Duration
+}
+
+func (s slowProvider) Name() string {
+	return "slow"
+}
+
+func (s slowProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	select {
+	case <-time.After(s.delay):
+		return s.dailyProvider.Rates(ctx, base, symbols, date)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestFallbackProviderRates(t *testing.T) {
+	down := failingProvider{dailyProvider{new(int)}, "down", errors.New("Connection refused.")}
+	slow := NewTimeoutProvider(slowProvider{dailyProvider{new(int)}, time.Second},
+		10*time.Millisecond)
+	daily := dailyProvider{new(int)}
+	date, _ := ParseDate("2016-10-28")
+
+	cases := []struct {
+		providers        []ConverterProvider
+		expectedProvider string
+		expectedFailures []string
+		expectedTimeout  bool
+	}{
+		{[]ConverterProvider{daily, down}, "daily", []string{}, false},
+		{[]ConverterProvider{down, daily}, "daily", []string{"down"}, false},
+		{[]ConverterProvider{slow, down, daily}, "daily", []string{"slow", "down"}, false},
+		{[]ConverterProvider{down, slow}, "", nil, false},
+		{[]ConverterProvider{slow}, "", nil, true},
+	}
+
+	for _, c := range cases {
+		rates, err := NewFallbackProvider(c.providers).Rates(context.Background(), "PLN",
+			[]string{"USD"}, date)
+		if c.expectedFailures == nil {
+			if err == nil || IsTimeout(err) != c.expectedTimeout {
+				t.Errorf("FallbackProvider.Rates(PLN) == \ngot: %v, %v \nexpected error "+
+					"(timeout: %t)", rates, err, c.expectedTimeout)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("FallbackProvider.Rates(PLN) returned error: %v", err)
+			continue
+		}
+
+		failed := make([]string, 0)
+		for _, failure := range rates.Failures {
+			failed = append(failed, failure.Provider)
+		}
+
+		if rates.Provider != c.expectedProvider || !reflect.DeepEqual(failed, c.expectedFailures) {
+			t.Errorf("FallbackProvider.Rates(PLN) == \ngot: %s (failed: %v), \nexpected %s "+
+				"(failed: %v)", rates.Provider, failed, c.expectedProvider, c.expectedFailures)
+		}
+	}
+}
+
+func TestProviderFailuresMarshalXML(t *testing.T) {
+	response := PairResponse{From: "PLN", To: "USD", Provider: "local",
+		Failures: ProviderFailures{{"fixerio", common.ErrUpstreamTimeout, "Timeout."}}}
+	expected := "<PairResponse><amount>0</amount><from>PLN</from><to>USD</to><rate>0</rate>" +
+		"<rounding></rounding><provider>local</provider><failures><failure>" +
+		"<provider>fixerio</provider><code>UPSTREAM_TIMEOUT</code><error>Timeout.</error>" +
+		"</failure></failures>" +
+		"<converted>0</converted></PairResponse>"
+
+	got, err := xml.Marshal(response)
+	if err != nil || string(got) != expected {
+		t.Errorf("xml.Marshal(%v) == \ngot: %s, %v \nexpected %s", response, got, err, expected)
+	}
+}
+
+func TestFallbackErrorDescribe(t *testing.T) {
+	cases := []struct {
+		failures ProviderFailures
+		timeout  bool
+		expected common.ErrorCode
+	}{
+		{
+			ProviderFailures{{"ecb", common.ErrUnsupportedCurrency, ""},
+				{"local", common.ErrUnsupportedCurrency, ""}},
+			false, common.ErrUnsupportedCurrency,
+		},
+		{
+			ProviderFailures{{"ecb", common.ErrRateNotFound, ""},
+				{"local", common.ErrUpstreamUnavailable, ""}},
+			false, common.ErrUpstreamUnavailable,
+		},
+		{
+			ProviderFailures{{"ecb", common.ErrInternal, ""}},
+			false, common.ErrUpstreamUnavailable,
+		},
+		{
+			ProviderFailures{{"ecb", common.ErrUpstreamTimeout, ""}},
+			true, common.ErrUpstreamTimeout,
+		},
+	}
+
+	for _, c := range cases {
+		err := &FallbackError{Base: "PLN", Failures: c.failures, timeout: c.timeout}
+		got := common.AsError(err).Code
+		if got != c.expected {
+			t.Errorf("AsError(%v) == \ngot: %s, \nexpected %s", c.failures, got, c.expected)
+		}
+	}
+}
+
+func TestFallbackProviderCanceled(t *testing.T) {
+	calls := new(int)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	provider := NewFallbackProvider([]ConverterProvider{
+		slowProvider{dailyProvider{new(int)}, time.Second}, dailyProvider{calls}})
+	if _, err := provider.Rates(ctx, "PLN", nil, time.Time{}); err != context.Canceled ||
+		*calls != 0 {
+		t.Errorf("FallbackProvider.Rates(PLN) == \ngot: %v (%d calls), \nexpected %v (0 calls)",
+			err, *calls, context.Canceled)
+	}
+}
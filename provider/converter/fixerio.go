@@ -13,29 +13,104 @@
 package converter
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/floreks/go-currency/common"
 )
 
 // Points to a fixer.io api
-const fixerIOApiUrl = "http://api.fixer.io/latest?base=%s"
+const fixerIOApiUrl = "http://api.fixer.io"
+
+// Fixer.io api path used to query latest rates
+const fixerIOLatest = "latest"
+
+// Types of fixer api errors caused by unknown currencies
+var fixerCurrencyErrors = map[string]bool{"invalid_base_currency": true,
+	"invalid_currency_codes": true}
+
+// Types of fixer api errors caused by dates out of range of published rates
+var fixerDateErrors = map[string]bool{"no_rates_available": true, "no_date_specified": true,
+	"invalid_date": true}
+
+// Types of fixer api errors by their codes, for responses containing only a code
+var fixerErrorTypes = map[int]string{106: "no_rates_available", 201: "invalid_base_currency",
+	202: "invalid_currency_codes", 301: "no_date_specified", 302: "invalid_date"}
+
+// FixerAPIError is an error returned by fixer api. Legacy api returns it as a plain string,
+// current one as an object with code, type and info.
+type FixerAPIError struct {
+	// Code - numeric error code, zero for plain string errors
+	Code int `json:"code"`
+	// Type - error type, e.g. invalid_access_key
+	Type string `json:"type"`
+	// Info - human readable description
+	Info string `json:"info"`
+}
+
+// UnmarshalJSON decodes error in both string and object form
+func (f *FixerAPIError) UnmarshalJSON(data []byte) error {
+	var info string
+	if json.Unmarshal(data, &info) == nil {
+		*f = FixerAPIError{Info: info}
+		return nil
+	}
+
+	type fixerAPIError FixerAPIError
+	return json.Unmarshal(data, (*fixerAPIError)(f))
+}
+
+// String returns code, type and description of the error
+func (f FixerAPIError) String() string {
+	if f.Code == 0 && len(f.Type) == 0 {
+		return f.Info
+	}
+
+	return fmt.Sprintf("%d %s: %s", f.Code, f.Type, f.Info)
+}
+
+// Returns error returned to clients. Fixer api rejects unknown currencies and dates out of range
+// of published rates. Other errors, e.g. of invalid api key or exceeded usage limit, are caused by
+// the upstream and their description is not revealed.
+func (f FixerAPIError) asError() *common.Error {
+	kind := f.Type
+	if len(kind) == 0 {
+		kind = fixerErrorTypes[f.Code]
+	}
 
-// FixerAPIError is an error string returned by fixer api
-type FixerAPIError string
+	switch {
+	case fixerDateErrors[kind]:
+		return common.NewError(common.ErrRateNotFound, "No rates published for requested date.")
+	case fixerCurrencyErrors[kind]:
+		return common.NewError(common.ErrUnsupportedCurrency,
+			"Requested currency is not supported.")
+	case f.Code == 0 && len(kind) == 0:
+		// Legacy api describes errors with a string only
+		if strings.Contains(strings.ToLower(f.Info), "date") {
+			return common.NewError(common.ErrRateNotFound, "%s", f.Info)
+		}
+
+		return common.NewError(common.ErrUnsupportedCurrency, "%s", f.Info)
+	}
+
+	return common.NewError(common.ErrUpstreamUnavailable, "Upstream provider rejected request.")
+}
 
 // FixerAPIRates is a map of current exchange rates returned by fixer api
-type FixerAPIRates map[string]float64
+type FixerAPIRates map[string]common.Decimal
 
 // FixerAPIResponse is a structure returned by fixer api (it's either error or base,date,rates)
 type FixerAPIResponse struct {
-	// Error - error string returned by fixer api
-	Error FixerAPIError `json:"error"`
+	// Error - error returned by fixer api, nil if rates were returned
+	Error *FixerAPIError `json:"error"`
 	// Base - base currency string returned by fixer api
 	Base string `json:"base"`
-	// Date - date on which request for exchange rates was made
+	// Date - date on which exchange rates were published
 	Date string `json:"date"`
 	// Rates - current exchange rates returned by fixer api
 	Rates FixerAPIRates `json:"rates"`
@@ -44,7 +119,11 @@ type FixerAPIResponse struct {
 // FixerIOProvider represents provider used to convert exchange rates based on Fixer.io service.
 // Implements ConverterProvider interface.
 type FixerIOProvider struct {
-	url string
+	url    string
+	apiKey string
+
+	// Client used for requests to fixer.io api, common.DefaultHTTPClient if nil
+	client *common.HTTPClient
 }
 
 // Name returns name of this provider
@@ -53,45 +132,108 @@ func (f FixerIOProvider) Name() string {
 }
 
 // Convert - takes the amount in one currency and converts it to other currencies
-func (f FixerIOProvider) Convert(amount float64, currency string) (*ConverterResponse, error) {
-	log.Printf("FixerIO provider - converting %.2f %s", amount, currency)
+func (f FixerIOProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
 
-	rates, err := f.getRates(currency)
+	log.Printf("FixerIO provider - converting %s %s", request.Amount, request.Currency)
+
+	rates, err := f.Rates(ctx, request.Currency, request.Symbols, request.Date)
 	if err != nil {
 		return nil, err
 	}
 
-	converted := f.convert(rates, amount)
-	return &ConverterResponse{Amount: amount, Currency: currency, Converted: converted}, nil
+	converted := f.convert(FixerAPIRates(rates.Rates), request.Amount, request.Rounding)
+	return newConverterResponse(request, rates, converted), nil
 }
 
-// Queries Fixer.io api and returns current exchange rates for currencies
-func (f FixerIOProvider) getRates(currency string) (FixerAPIRates, error) {
+// Rates queries Fixer.io api for exchange rates of given base currency. Latest rates are requested
+// if date is zero, otherwise rates of the last business day up to given date. Fixer.io itself
+// falls back to the previous business day on holidays. Only rates of given symbols are requested,
+// unless symbols are empty.
+func (f FixerIOProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	fixerAPIResponse, err := f.getRates(ctx, base, symbols, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeRates{Base: fixerAPIResponse.Base, Date: fixerAPIResponse.Date,
+		Rates: selectRates(Rates(fixerAPIResponse.Rates), base, symbols)}, nil
+}
+
+// Capabilities returns currencies served by Fixer.io, which publishes rates of the ECB
+func (f FixerIOProvider) Capabilities() Capabilities {
+	return Capabilities{Bases: ecbCurrencies, Targets: ecbCurrencies, Historical: true}
+}
+
+// Queries Fixer.io api and returns exchange rates for currencies
+func (f FixerIOProvider) getRates(ctx context.Context, currency string, symbols []string,
+	date time.Time) (*FixerAPIResponse, error) {
+
+	query := url.Values{}
+	query.Set("base", currency)
+	if len(symbols) > 0 {
+		query.Set("symbols", strings.Join(symbols, ","))
+	}
+
+	if len(f.apiKey) > 0 {
+		query.Set("access_key", f.apiKey)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s?%s", strings.TrimSuffix(f.url, "/"), f.datePath(date),
+		query.Encode())
+
 	fixerAPIResponse := new(FixerAPIResponse)
-	err := common.GetJson(fmt.Sprintf(f.url, currency), &fixerAPIResponse)
+	err := httpClient(f.client).GetJson(ctx, endpoint, &fixerAPIResponse)
 	if err != nil {
 		log.Printf("Error during request to fixer.io: %s", err)
+		if httpErr, ok := err.(*common.HTTPError); ok && !httpErr.Temporary() {
+			if json.Unmarshal([]byte(httpErr.Body), fixerAPIResponse) == nil &&
+				fixerAPIResponse.Error != nil {
+				return nil, fixerAPIResponse.Error.asError()
+			}
+		}
+
 		return nil, err
 	}
 
-	if len(fixerAPIResponse.Error) != 0 {
+	if fixerAPIResponse.Error != nil {
 		log.Printf("Fixer.io returned error: %s", fixerAPIResponse.Error)
-		return nil, errors.New(string(fixerAPIResponse.Error))
+		return nil, fixerAPIResponse.Error.asError()
 	}
 
-	return fixerAPIResponse.Rates, nil
+	return fixerAPIResponse, nil
 }
 
-// Does the actual conversion based on rates map and amount of currency
-func (f FixerIOProvider) convert(rates FixerAPIRates, amount float64) ConvertedRates {
-	for cur, rate := range rates {
-		rates[cur] = common.Round((rate * amount), 2)
+// Returns path of Fixer.io api endpoint serving rates of given date
+func (f FixerIOProvider) datePath(date time.Time) string {
+	if date.IsZero() {
+		return fixerIOLatest
 	}
 
-	return ConvertedRates(rates)
+	return lastBusinessDay(date).Format(DateFormat)
+}
+
+// Does the actual conversion based on rates map, amount of currency and rounding mode
+func (f FixerIOProvider) convert(rates FixerAPIRates, amount common.Decimal,
+	rounding common.RoundingMode) ConvertedRates {
+
+	return convertRates(rates, amount, rounding)
 }
 
 // NewFixerIOProvider returns initialized fixer io provider object
 func NewFixerIOProvider() FixerIOProvider {
 	return FixerIOProvider{url: fixerIOApiUrl}
 }
+
+// NewFixerIOProviderWithURL returns fixer io provider object using api served under given url, or
+// the default one if url is empty. Given api key and headers are sent with every request, unless
+// they are empty.
+func NewFixerIOProviderWithURL(url, apiKey string, headers map[string]string) FixerIOProvider {
+	if len(url) == 0 {
+		url = fixerIOApiUrl
+	}
+
+	return FixerIOProvider{url: url, apiKey: apiKey, client: newHTTPClient(headers)}
+}
@@ -13,40 +13,74 @@
 package converter
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
 )
 
 func TestConvertFixerIO(t *testing.T) {
 	provider := new(FixerIOProvider)
 	cases := []struct {
 		rates    FixerAPIRates
-		amount   float64
-		expected ConvertedRates
+		amount   common.Decimal
+		rounding common.RoundingMode
+		expected map[string]string
 	}{
 		{
 			FixerAPIRates{
-				"USD": 1.234,
-				"PLN": 1.01,
-				"EUR": 0.05,
-				"SEK": 0.00055,
+				"USD": common.MustParseDecimal("1.234"),
+				"PLN": common.MustParseDecimal("1.01"),
+				"EUR": common.MustParseDecimal("0.05"),
+				"SEK": common.MustParseDecimal("0.00055"),
+			},
+			common.NewDecimal(100, 0),
+			common.HalfUp,
+			map[string]string{
+				"USD": "123.40",
+				"PLN": "101.00",
+				"EUR": "5.00",
+				"SEK": "0.06",
 			},
-			100,
-			ConvertedRates{
-				"USD": 123.4,
-				"PLN": 101,
-				"EUR": 5,
-				"SEK": 0.06,
+		},
+		{
+			FixerAPIRates{"USD": common.MustParseDecimal("0.3")},
+			common.MustParseDecimal("0.1"),
+			common.HalfUp,
+			map[string]string{"USD": "0.03"},
+		},
+		{
+			FixerAPIRates{
+				"USD": common.MustParseDecimal("0.25292"),
+				"JPY": common.MustParseDecimal("26.565"),
+				"KWD": common.MustParseDecimal("0.07655"),
 			},
+			common.NewDecimal(10, 0),
+			common.HalfEven,
+			map[string]string{"USD": "2.53", "JPY": "266", "KWD": "0.766"},
+		},
+		{
+			FixerAPIRates{"USD": common.MustParseDecimal("0.25292")},
+			common.NewDecimal(10, 0),
+			common.Floor,
+			map[string]string{"USD": "2.52"},
 		},
 	}
 
 	for _, c := range cases {
-		actual := provider.convert(c.rates, c.amount)
+		actual := ratesToStrings(provider.convert(c.rates, c.amount, c.rounding))
 
 		if !reflect.DeepEqual(actual, c.expected) {
-			t.Errorf("FixerIOProvider.convert(%v, %v) == \ngot: %v, \nexpected %v",
-				c.rates, c.amount, actual, c.expected)
+			t.Errorf("FixerIOProvider.convert(%v, %v, %s) == \ngot: %v, \nexpected %v",
+				c.rates, c.amount, c.rounding, actual, c.expected)
 		}
 	}
 }
@@ -66,3 +100,90 @@ func TestNewFixerIOProvider(t *testing.T) {
 		}
 	}
 }
+
+func TestFixerIOQuery(t *testing.T) {
+	queries := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		fmt.Fprint(w, `{"base":"PLN","date":"2016-10-31","rates":{"USD":0.25292}}`)
+	}))
+	defer server.Close()
+
+	provider := NewFixerIOProviderWithURL(server.URL, "key&base=EUR", nil)
+	if _, err := provider.Rates(context.Background(), "PLN", []string{"USD", "EUR"},
+		time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := url.Values{"base": {"PLN"}, "symbols": {"USD,EUR"},
+		"access_key": {"key&base=EUR"}}
+	if actual := <-queries; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FixerIOProvider.Rates(PLN) query == \ngot: %v, \nexpected %v", actual, expected)
+	}
+}
+
+func TestFixerIODatePath(t *testing.T) {
+	provider := NewFixerIOProvider()
+	cases := []struct {
+		date     string
+		expected string
+	}{
+		{"", fixerIOLatest},
+		{"2016-10-31", "2016-10-31"},
+		{"2016-10-30", "2016-10-28"},
+	}
+
+	for _, c := range cases {
+		var date time.Time
+		if c.date != "" {
+			date, _ = ParseDate(c.date)
+		}
+
+		if actual := provider.datePath(date); actual != c.expected {
+			t.Errorf("FixerIOProvider.datePath(%s) == \ngot: %s, \nexpected %s", c.date, actual,
+				c.expected)
+		}
+	}
+}
+
+func TestFixerAPIErrorAsError(t *testing.T) {
+	cases := []struct {
+		response string
+		expected common.ErrorCode
+	}{
+		{`{"error":"Invalid base"}`, common.ErrUnsupportedCurrency},
+		{`{"error":"Invalid date"}`, common.ErrRateNotFound},
+		{`{"success":false,"error":{"code":101,"type":"invalid_access_key",` +
+			`"info":"You have not supplied a valid API Access Key."}}`,
+			common.ErrUpstreamUnavailable},
+		{`{"success":false,"error":{"code":104,"type":"usage_limit_reached"}}`,
+			common.ErrUpstreamUnavailable},
+		{`{"success":false,"error":{"code":105,"type":"function_access_restricted"}}`,
+			common.ErrUpstreamUnavailable},
+		{`{"success":false,"error":{"code":201,"type":"invalid_base_currency"}}`,
+			common.ErrUnsupportedCurrency},
+		{`{"success":false,"error":{"code":202}}`, common.ErrUnsupportedCurrency},
+		{`{"success":false,"error":{"code":302,"type":"invalid_date"}}`,
+			common.ErrRateNotFound},
+		{`{"success":false,"error":{"code":106}}`, common.ErrRateNotFound},
+	}
+
+	for _, c := range cases {
+		response := new(FixerAPIResponse)
+		if err := json.Unmarshal([]byte(c.response), response); err != nil {
+			t.Fatal(err)
+		}
+
+		if response.Error == nil {
+			t.Errorf("FixerAPIError.asError(%s) == \ngot: no error, \nexpected %s", c.response,
+				c.expected)
+			continue
+		}
+
+		if actual := response.Error.asError(); actual.Code != c.expected ||
+			strings.Contains(actual.Message, "Access Key") {
+			t.Errorf("FixerAPIError.asError(%s) == \ngot: %v, \nexpected %s", c.response,
+				actual, c.expected)
+		}
+	}
+}
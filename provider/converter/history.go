@@ -0,0 +1,46 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// DateFormat is a format of dates used by providers and accepted in requests (YYYY-MM-DD)
+const DateFormat = "2006-01-02"
+
+// ParseDate parses date given in DateFormat
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return time.Time{}, common.NewError(common.ErrInvalidParameter,
+			"Provided date is invalid: '%s'. Expected format: YYYY-MM-DD.", value)
+	}
+
+	return date, nil
+}
+
+// Returns given date or the closest preceding weekday if it falls on a weekend. Rates are not
+// published on weekends.
+func lastBusinessDay(date time.Time) time.Time {
+	switch date.Weekday() {
+	case time.Saturday:
+		return date.AddDate(0, 0, -1)
+	case time.Sunday:
+		return date.AddDate(0, 0, -2)
+	}
+
+	return date
+}
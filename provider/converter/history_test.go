@@ -0,0 +1,59 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastBusinessDay(t *testing.T) {
+	cases := []struct {
+		date     string
+		expected string
+	}{
+		{"2016-10-31", "2016-10-31"},
+		{"2016-11-04", "2016-11-04"},
+		{"2016-11-05", "2016-11-04"},
+		{"2016-11-06", "2016-11-04"},
+	}
+
+	for _, c := range cases {
+		date, _ := time.Parse(DateFormat, c.date)
+		actual := lastBusinessDay(date).Format(DateFormat)
+
+		if actual != c.expected {
+			t.Errorf("lastBusinessDay(%s) == \ngot: %s, \nexpected %s", c.date, actual, c.expected)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		value         string
+		expectedError bool
+	}{
+		{"2016-10-31", false},
+		{"2016-02-30", true},
+		{"31.10.2016", true},
+		{"", true},
+	}
+
+	for _, c := range cases {
+		_, err := ParseDate(c.value)
+
+		if (err != nil) != c.expectedError {
+			t.Errorf("ParseDate(%s) returned error: %v", c.value, err)
+		}
+	}
+}
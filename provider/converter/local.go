@@ -13,11 +13,12 @@
 package converter
 
 import (
-	"encoding/json"
-	"fmt"
-	"github.com/floreks/go-currency/common"
+	"context"
 	"log"
 	"strings"
+	"time"
+
+	"github.com/floreks/go-currency/common"
 )
 
 const (
@@ -42,22 +43,29 @@ const (
 	currencyEUR = "EUR"
 )
 
-// Rates is a local rates map. Created for code clarity.
-type Rates map[string]float64
+// Saved exchange rates snapshots by their base currency
+var localBaseRates = map[string][]string{
+	currencyEUR: {baseEUR},
+	currencyPLN: {basePLN},
+	currencyUSD: {baseUSD},
+}
+
+// Built-in snapshots served when no snapshot directory is given or it lacks requested rates
+var builtinSnapshots = parseBuiltinSnapshots()
 
 // LocalBaseRates is a structure used for local conversion. Similar to FixerAPIResponse.
-type LocalBaseRates struct {
-	// Base - base currency
-	Base string
-	// Date - date on which local base rates were saved
-	Date string
-	// Rates - exchange rates
-	Rates Rates
-}
+type LocalBaseRates ExchangeRates
 
-// LocalProvider represents localprovider used to convert exchange rates. Supports only 3 base
-// currencies: PLN, EUR, USD. Implements ConverterProvider interface.
-type LocalProvider struct{}
+// LocalProvider represents localprovider used to convert exchange rates. Has built-in rates of 3
+// base currencies: PLN, EUR, USD, and optionally snapshots loaded from a directory. Rates of any
+// other base currency are derived through pivot currency. Implements ConverterProvider interface.
+type LocalProvider struct {
+	// Currency through which rates of other base currencies are derived. DefaultPivot if empty.
+	pivot string
+
+	// Directory of snapshot files, nil if only built-in snapshots are served
+	directory *SnapshotDirectory
+}
 
 // Name returns name of this provider
 func (l LocalProvider) Name() string {
@@ -65,48 +73,160 @@ func (l LocalProvider) Name() string {
 }
 
 // Convert - takes the amount in one currency and converts it to other currencies
-func (l LocalProvider) Convert(amount float64, currency string) (*ConverterResponse, error) {
-	log.Printf("Local provider - converting %.2f %s", amount, currency)
+func (l LocalProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	log.Printf("Local provider - converting %s %s", request.Amount, request.Currency)
 
-	baseRates, err := l.getBase(currency)
+	rates, err := l.Rates(ctx, request.Currency, request.Symbols, request.Date)
 	if err != nil {
 		return nil, err
 	}
 
-	converted := l.convert(baseRates.Rates, amount)
-	return &ConverterResponse{Amount: amount, Currency: currency, Converted: converted}, nil
+	converted := l.convert(rates.Rates, request.Amount, request.Rounding)
+	return newConverterResponse(request, rates, converted), nil
+}
+
+// Rates returns saved exchange rates of given base currency limited to given symbols. Newest
+// snapshot saved on or before given date is used, or the newest one if date is zero. If rates of
+// base currency were not saved they are derived through pivot currency.
+func (l LocalProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	if _, exists := l.snapshots()[strings.ToUpper(base)]; exists {
+		baseRates, err := l.getBase(base, date)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ExchangeRates{Base: baseRates.Base, Date: baseRates.Date,
+			Rates: selectRates(baseRates.Rates, baseRates.Base, symbols)}, nil
+	}
+
+	pivotRates, err := l.getBase(l.getPivot(), date)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := pivotRates.Rates[strings.ToUpper(base)]; !exists {
+		log.Printf("Currency %s not supported by local provider.", base)
+		return nil, common.NewError(common.ErrUnsupportedCurrency,
+			"Currency %s not supported by local provider.", base)
+	}
+
+	return Triangulate((*ExchangeRates)(pivotRates), strings.ToUpper(base), symbols)
+}
+
+// Capabilities returns currencies of saved snapshots. Rates of every currency saved in pivot
+// snapshots can be derived, so all of them can be used as a base.
+func (l LocalProvider) Capabilities() Capabilities {
+	currencies := make(map[string]bool)
+	for currency, snapshots := range l.snapshots() {
+		currencies[currency] = true
+		for _, snapshot := range snapshots {
+			for target := range snapshot.Rates {
+				currencies[target] = true
+			}
+		}
+	}
+
+	sorted := sortedCurrencies(currencies)
+	return Capabilities{Bases: sorted, Targets: sorted, Historical: true}
+}
+
+func (l LocalProvider) getPivot() string {
+	if len(l.pivot) == 0 {
+		return DefaultPivot
+	}
+
+	return l.pivot
 }
 
 // Returns base exchange rate structure that is used for further conversion or error if given
-// currency is not supported.
-func (l LocalProvider) getBase(currency string) (*LocalBaseRates, error) {
-	var baseJsonString string
-	result := new(LocalBaseRates)
-
-	switch strings.ToUpper(currency) {
-	case currencyEUR:
-		baseJsonString = baseEUR
-	case currencyPLN:
-		baseJsonString = basePLN
-	case currencyUSD:
-		baseJsonString = baseUSD
-	default:
+// currency is not supported. Newest snapshot saved on or before given date is returned, weekends
+// and holidays fall back this way to the previous business day.
+func (l LocalProvider) getBase(currency string, date time.Time) (*LocalBaseRates, error) {
+	var result *LocalBaseRates
+
+	snapshots, exists := l.snapshots()[strings.ToUpper(currency)]
+	if !exists {
 		log.Printf("Currency %s not supported by local provider.", currency)
-		return nil, fmt.Errorf("Currency %s not supported by local provider.", currency)
+		return nil, common.NewError(common.ErrUnsupportedCurrency,
+			"Currency %s not supported by local provider.", currency)
 	}
 
-	if err := json.Unmarshal([]byte(baseJsonString), &result); err != nil {
-		return nil, err
+	for _, snapshot := range snapshots {
+		if !date.IsZero() && snapshot.Date > date.Format(DateFormat) {
+			continue
+		}
+
+		if result == nil || snapshot.Date > result.Date {
+			result = snapshot
+		}
+	}
+
+	if result == nil {
+		log.Printf("Local provider has no %s rates for %s.", currency, date.Format(DateFormat))
+		return nil, common.NewError(common.ErrRateNotFound, "Local provider has no %s rates for %s.",
+			currency, date.Format(DateFormat))
 	}
 
 	return result, nil
 }
 
-// Does the actual conversion based on rates map and amount of currency
-func (LocalProvider) convert(rates Rates, amount float64) ConvertedRates {
-	for cur, rate := range rates {
-		rates[cur] = common.Round((rate * amount), 2)
+// Returns snapshots served by this provider by their base currency
+func (l LocalProvider) snapshots() Snapshots {
+	if l.directory == nil {
+		return builtinSnapshots
 	}
 
-	return ConvertedRates(rates)
+	return l.directory.Snapshots()
+}
+
+// Does the actual conversion based on rates map, amount of currency and rounding mode
+func (LocalProvider) convert(rates Rates, amount common.Decimal,
+	rounding common.RoundingMode) ConvertedRates {
+
+	return convertRates(rates, amount, rounding)
+}
+
+// Parses snapshots compiled into the binary
+func parseBuiltinSnapshots() Snapshots {
+	result := make(Snapshots)
+	for _, jsonStrings := range localBaseRates {
+		for _, jsonString := range jsonStrings {
+			snapshot, err := ParseSnapshot([]byte(jsonString))
+			if err != nil {
+				panic(err)
+			}
+
+			result.add(snapshot)
+		}
+	}
+
+	return result
+}
+
+// LocalBases returns sorted base currencies of built-in snapshots
+func LocalBases() []string {
+	bases := make(map[string]bool, len(localBaseRates))
+	for base := range localBaseRates {
+		bases[base] = true
+	}
+
+	return sortedCurrencies(bases)
+}
+
+// NewLocalProvider returns local provider deriving rates of base currencies that were not saved
+// through given pivot currency. Pivot has to be one of saved base currencies.
+func NewLocalProvider(pivot string) LocalProvider {
+	return LocalProvider{pivot: pivot}
+}
+
+// NewLocalProviderWithDirectory returns local provider serving snapshots of given directory on top
+// of built-in ones. Directory is checked for changes at most once per given interval.
+func NewLocalProviderWithDirectory(pivot, directory string,
+	interval time.Duration) LocalProvider {
+
+	return LocalProvider{pivot: pivot, directory: NewSnapshotDirectory(directory, interval)}
 }
@@ -13,35 +13,38 @@
 package converter
 
 import (
-	"errors"
+	"context"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
 )
 
 func TestConvertLocal(t *testing.T) {
 	provider := new(LocalProvider)
 	cases := []struct {
 		rates    Rates
-		amount   float64
-		expected ConvertedRates
+		amount   common.Decimal
+		expected map[string]string
 	}{
 		{
 			Rates{
-				"USD": 1,
-				"PLN": 1.5,
-				"EUR": 0.5,
+				"USD": common.NewDecimal(1, 0),
+				"PLN": common.MustParseDecimal("1.5"),
+				"EUR": common.MustParseDecimal("0.5"),
 			},
-			10,
-			ConvertedRates{
-				"USD": 10,
-				"PLN": 15,
-				"EUR": 5,
+			common.NewDecimal(10, 0),
+			map[string]string{
+				"USD": "10.00",
+				"PLN": "15.00",
+				"EUR": "5.00",
 			},
 		},
 	}
 
 	for _, c := range cases {
-		actual := provider.convert(c.rates, c.amount)
+		actual := ratesToStrings(provider.convert(c.rates, c.amount, common.HalfUp))
 
 		if !reflect.DeepEqual(actual, c.expected) {
 			t.Errorf("LocalProvider.convert(%v, %v) == \ngot: %v, \nexpected %v",
@@ -54,43 +57,139 @@ func TestConvert(t *testing.T) {
 	provider := new(LocalProvider)
 	cases := []struct {
 		currency      string
-		amount        float64
-		expected      *ConverterResponse
+		amount        common.Decimal
+		expected      map[string]string
 		expectedError error
 	}{
 		{
-			currencyPLN, 10,
-			&ConverterResponse{
-				Amount:   10,
-				Currency: currencyPLN,
-				Converted: ConvertedRates{
-					"IDR": 32982, "JPY": 265.65, "ZAR": 34.31, "BRL": 8.05, "HRK": 17.35,
-					"MXN": 47.87, "MYR": 10.62, "NOK": 20.88, "USD": 2.53, "CNY": 17.14,
-					"HKD": 19.61, "PHP": 122.61, "RUB": 160.01, "CHF": 2.5, "NZD": 3.54,
-					"SEK": 22.79, "EUR": 2.31, "ILS": 9.74, "GBP": 2.08, "KRW": 2899.6,
-					"BGN": 4.52, "CAD": 3.39, "CZK": 62.44, "DKK": 17.19, "HUF": 712.69,
-					"INR": 168.89, "AUD": 3.33, "TRY": 7.85, "SGD": 3.52, "THB": 88.56,
-					"RON": 10.41,
-				}}, nil,
+			currencyPLN, common.NewDecimal(10, 0),
+			map[string]string{
+				"IDR": "32982", "JPY": "266", "ZAR": "34.31", "BRL": "8.05", "HRK": "17.35",
+				"MXN": "47.87", "MYR": "10.62", "NOK": "20.88", "USD": "2.53", "CNY": "17.14",
+				"HKD": "19.61", "PHP": "122.61", "RUB": "160.01", "CHF": "2.50", "NZD": "3.54",
+				"SEK": "22.79", "EUR": "2.31", "ILS": "9.74", "GBP": "2.08", "KRW": "2900",
+				"BGN": "4.52", "CAD": "3.39", "CZK": "62.44", "DKK": "17.19", "HUF": "712.69",
+				"INR": "168.89", "AUD": "3.33", "TRY": "7.85", "SGD": "3.52", "THB": "88.56",
+				"RON": "10.41",
+			}, nil,
 		},
 		{
-			"ERR_CURRENCY", 10,
+			"ERR_CURRENCY", common.NewDecimal(10, 0),
 			nil,
-			errors.New("Currency ERR_CURRENCY not supported by local provider."),
+			common.NewError(common.ErrUnsupportedCurrency,
+				"Currency ERR_CURRENCY not supported by local provider."),
 		},
 	}
 
 	for _, c := range cases {
-		actual, err := provider.Convert(c.amount, c.currency)
+		response, err := provider.Convert(context.Background(), ConverterRequest{Amount: c.amount,
+			Currency: c.currency, Rounding: common.HalfUp})
 
 		if !reflect.DeepEqual(err, c.expectedError) {
-			t.Errorf("LocalProvider.Convert(%f, %s) == \ngot: %s, \nexpected: %s",
+			t.Errorf("LocalProvider.Convert(%s, %s) == \ngot: %s, \nexpected: %s",
 				c.amount, c.currency, err, c.expectedError)
 		}
 
+		var actual map[string]string
+		if response != nil {
+			actual = ratesToStrings(response.Converted)
+
+			if !response.Amount.Equal(c.amount) || response.Currency != c.currency {
+				t.Errorf("LocalProvider.Convert(%s, %s) == \ngot: %s %s, \nexpected: %s %s",
+					c.amount, c.currency, response.Amount, response.Currency, c.amount, c.currency)
+			}
+		}
+
 		if !reflect.DeepEqual(actual, c.expected) {
-			t.Errorf("LocalProvider.Convert(%f, %s) == \ngot: %v, \nexpected: %v",
+			t.Errorf("LocalProvider.Convert(%s, %s) == \ngot: %v, \nexpected: %v",
 				c.amount, c.currency, actual, c.expected)
 		}
 	}
 }
+
+func TestLocalRates(t *testing.T) {
+	provider := new(LocalProvider)
+	cases := []struct {
+		base     string
+		symbols  []string
+		expected map[string]string
+	}{
+		{currencyEUR, []string{"USD", "PLN"}, map[string]string{"USD": "1.0946", "PLN": "4.3278"}},
+		{currencyUSD, []string{"USD"}, map[string]string{"USD": "1"}},
+	}
+
+	for _, c := range cases {
+		rates, err := provider.Rates(context.Background(), c.base, c.symbols, time.Time{})
+		if err != nil {
+			t.Errorf("LocalProvider.Rates(%s, %v) returned error: %v", c.base, c.symbols, err)
+			continue
+		}
+
+		actual := ratesToStrings(ConvertedRates(rates.Rates))
+		if !reflect.DeepEqual(actual, c.expected) || rates.Date != "2016-10-31" {
+			t.Errorf("LocalProvider.Rates(%s, %v) == \ngot: %v %s, \nexpected %v", c.base,
+				c.symbols, actual, rates.Date, c.expected)
+		}
+	}
+}
+
+func TestLocalDerivedRates(t *testing.T) {
+	cases := []struct {
+		provider      LocalProvider
+		base          string
+		symbols       []string
+		expected      map[string]string
+		expectedPivot string
+	}{
+		{LocalProvider{}, "SEK", []string{"NOK"}, map[string]string{"NOK": "0.915813482007"},
+			currencyEUR},
+		{NewLocalProvider(currencyUSD), "sek", []string{"NOK"},
+			map[string]string{"NOK": "0.915815986863"}, currencyUSD},
+		{LocalProvider{}, "PLN", []string{"EUR"}, map[string]string{"EUR": "0.23106"}, ""},
+	}
+
+	for _, c := range cases {
+		rates, err := c.provider.Rates(context.Background(), c.base, c.symbols, time.Time{})
+		if err != nil {
+			t.Errorf("LocalProvider.Rates(%s, %v) returned error: %v", c.base, c.symbols, err)
+			continue
+		}
+
+		actual := ratesToStrings(ConvertedRates(rates.Rates))
+		if !reflect.DeepEqual(actual, c.expected) || rates.Pivot != c.expectedPivot {
+			t.Errorf("LocalProvider.Rates(%s, %v) == \ngot: %v %s, \nexpected %v %s", c.base,
+				c.symbols, actual, rates.Pivot, c.expected, c.expectedPivot)
+		}
+	}
+}
+
+func TestLocalHistoricalRates(t *testing.T) {
+	provider := new(LocalProvider)
+	cases := []struct {
+		base          string
+		date          string
+		expectedDate  string
+		expectedError bool
+	}{
+		{currencyPLN, "2016-10-31", "2016-10-31", false},
+		{currencyPLN, "2016-11-05", "2016-10-31", false},
+		{"SEK", "2017-01-01", "2016-10-31", false},
+		{currencyPLN, "2016-10-30", "", true},
+		{"SEK", "2016-10-30", "", true},
+	}
+
+	for _, c := range cases {
+		date, _ := ParseDate(c.date)
+		rates, err := provider.Rates(context.Background(), c.base, []string{currencyUSD}, date)
+
+		if (err != nil) != c.expectedError {
+			t.Errorf("LocalProvider.Rates(%s, %s) returned error: %v", c.base, c.date, err)
+			continue
+		}
+
+		if rates != nil && rates.Date != c.expectedDate {
+			t.Errorf("LocalProvider.Rates(%s, %s) == \ngot: %s, \nexpected %s", c.base, c.date,
+				rates.Date, c.expectedDate)
+		}
+	}
+}
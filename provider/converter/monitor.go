@@ -0,0 +1,149 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Health states of a provider
+const (
+	// HealthUnknown - provider has not been asked for rates yet
+	HealthUnknown = "unknown"
+	// HealthUp - last request for rates succeeded
+	HealthUp = "up"
+	// HealthDown - last request for rates failed
+	HealthDown = "down"
+)
+
+// ProviderStatus describes outcome of the recent requests for rates made to a provider
+type ProviderStatus struct {
+	// Health - current health of the provider
+	Health string
+	// LastSuccess - time of the last successful request, zero if there was none
+	LastSuccess time.Time
+	// LastFailure - time of the last failed request, zero if there was none
+	LastFailure time.Time
+	// LastError - error of the last failed request
+	LastError string
+	// Circuit - state of circuit breaker guarding the provider, empty if there is none
+	Circuit string
+}
+
+// StatusReporter is implemented by providers that keep track of their status
+type StatusReporter interface {
+	// Status returns current status of the provider
+	Status() ProviderStatus
+}
+
+// MonitoringProvider wraps any provider and keeps track of its status. Implements
+// ConverterProvider, TimeSeriesProvider and StatusReporter interfaces.
+type MonitoringProvider struct {
+	provider ConverterProvider
+
+	mu     *sync.RWMutex
+	status *ProviderStatus
+	now    func() time.Time
+}
+
+// Name returns name of the wrapped provider
+func (m MonitoringProvider) Name() string {
+	return m.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (m MonitoringProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := m.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of the wrapped provider and records whether they were served
+func (m MonitoringProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	rates, err := m.provider.Rates(ctx, base, symbols, date)
+	m.record(ctx, err)
+	return rates, err
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+// and records whether they were served
+func (m MonitoringProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	series, err := TimeSeries(ctx, m.provider, base, symbols, start, end)
+	m.record(ctx, err)
+	return series, err
+}
+
+// Updates status with outcome of a request passed to the provider
+func (m MonitoringProvider) record(ctx context.Context, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Requests abandoned by clients say nothing about health of the provider
+	if err != nil && ctx.Err() == context.Canceled {
+		return
+	}
+
+	// Requests the provider rejected, i.e. for unsupported currencies, were answered
+	if err != nil && common.AsError(err).Status() < http.StatusInternalServerError {
+		return
+	}
+
+	if err != nil {
+		m.status.Health = HealthDown
+		m.status.LastFailure = m.now()
+		m.status.LastError = common.AsError(err).Message
+		return
+	}
+
+	m.status.Health = HealthUp
+	m.status.LastSuccess = m.now()
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (m MonitoringProvider) Capabilities() Capabilities {
+	return m.provider.Capabilities()
+}
+
+// Status returns current status of the wrapped provider
+func (m MonitoringProvider) Status() ProviderStatus {
+	m.mu.RLock()
+	status := *m.status
+	m.mu.RUnlock()
+
+	if reporter, ok := m.provider.(CircuitReporter); ok {
+		status.Circuit = reporter.Circuit()
+	}
+
+	return status
+}
+
+// NewMonitoringProvider returns provider keeping track of status of given provider
+func NewMonitoringProvider(provider ConverterProvider) MonitoringProvider {
+	return MonitoringProvider{provider: provider, mu: new(sync.RWMutex),
+		status: &ProviderStatus{Health: HealthUnknown}, now: time.Now}
+}
@@ -0,0 +1,71 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Provider failing rates requests while its flag is set
+type flakyProvider struct {
+	dailyProvider
+	failing *bool
+}
+
+func (f flakyProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	if *f.failing {
+		return nil, errors.New("Service unavailable.")
+	}
+
+	return f.dailyProvider.Rates(ctx, base, symbols, date)
+}
+
+func TestMonitoringProviderStatus(t *testing.T) {
+	failing := new(bool)
+	provider := NewMonitoringProvider(flakyProvider{dailyProvider{new(int)}, failing})
+	now := time.Date(2016, 10, 31, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	if status := provider.Status(); status.Health != HealthUnknown {
+		t.Errorf("Status() == \ngot: %s, \nexpected %s", status.Health, HealthUnknown)
+	}
+
+	cases := []struct {
+		failing             bool
+		expectedHealth      string
+		expectedLastSuccess time.Time
+		expectedLastError   string
+	}{
+		{false, HealthUp, now, ""},
+		{true, HealthDown, now, "Internal error."},
+		{false, HealthUp, now.Add(2 * time.Minute), "Internal error."},
+	}
+
+	for _, c := range cases {
+		*failing = c.failing
+		provider.Rates(context.Background(), "PLN", nil, time.Time{})
+		status := provider.Status()
+		if status.Health != c.expectedHealth || !status.LastSuccess.Equal(c.expectedLastSuccess) ||
+			status.LastError != c.expectedLastError {
+			t.Errorf("Status() == \ngot: %v, \nexpected %s %s %s", status, c.expectedHealth,
+				c.expectedLastSuccess, c.expectedLastError)
+		}
+
+		now = now.Add(time.Minute)
+	}
+}
@@ -0,0 +1,61 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"time"
+)
+
+// NamedProvider serves wrapped provider under a different name. Implements ConverterProvider and
+// TimeSeriesProvider interfaces.
+type NamedProvider struct {
+	provider ConverterProvider
+	name     string
+}
+
+// Name returns name given to the wrapped provider
+func (n NamedProvider) Name() string {
+	return n.name
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (n NamedProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	return n.provider.Convert(ctx, request)
+}
+
+// Rates returns exchange rates of the wrapped provider
+func (n NamedProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	return n.provider.Rates(ctx, base, symbols, date)
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+func (n NamedProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	return TimeSeries(ctx, n.provider, base, symbols, start, end)
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (n NamedProvider) Capabilities() Capabilities {
+	return n.provider.Capabilities()
+}
+
+// NewNamedProvider returns given provider served under given name
+func NewNamedProvider(provider ConverterProvider, name string) NamedProvider {
+	return NamedProvider{provider: provider, name: name}
+}
@@ -0,0 +1,454 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Context key of tenant on whose behalf rates are requested
+type tenantKey struct{}
+
+// WithTenant returns context of requests made on behalf of given tenant. Overrides scoped to the
+// tenant are applied to rates requested with it.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	if len(tenant) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns tenant on whose behalf rates are requested, empty if none
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// Override pins exchange rate of a currency pair regardless of rates served by providers
+type Override struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"override"`
+	// ID - identifier assigned when override is created
+	ID string `json:"id" xml:"id"`
+	// From - base currency
+	From string `json:"from" xml:"from"`
+	// To - target currency
+	To string `json:"to" xml:"to"`
+	// Rate - price of one unit of base currency in target currency
+	Rate common.Decimal `json:"rate" xml:"rate"`
+	// ValidFrom - first day (YYYY-MM-DD) on which override applies, unbounded if empty
+	ValidFrom string `json:"validFrom,omitempty" xml:"validFrom,omitempty"`
+	// ValidTo - last day (YYYY-MM-DD) on which override applies, unbounded if empty
+	ValidTo string `json:"validTo,omitempty" xml:"validTo,omitempty"`
+	// Tenant - the only tenant to which override applies, applies to everyone if empty
+	Tenant string `json:"tenant,omitempty" xml:"tenant,omitempty"`
+}
+
+// Normalize validates override and returns it with normalized currency codes
+func (o Override) Normalize() (Override, error) {
+	var err error
+	if o.From, err = common.NormalizeCurrency(o.From); err != nil {
+		return o, common.AsError(err).WithParameter("from")
+	}
+
+	if o.To, err = common.NormalizeCurrency(o.To); err != nil {
+		return o, common.AsError(err).WithParameter("to")
+	}
+
+	if o.From == o.To {
+		return o, common.NewError(common.ErrInvalidParameter,
+			"Override of %s to itself is not allowed.", o.From).WithParameter("to")
+	}
+
+	if o.Rate.Sign() <= 0 {
+		return o, common.NewError(common.ErrInvalidParameter, "Rate %s has to be positive.",
+			o.Rate).WithParameter("rate")
+	}
+
+	for name, day := range map[string]string{"validFrom": o.ValidFrom, "validTo": o.ValidTo} {
+		if len(day) == 0 {
+			continue
+		}
+
+		if _, err := ParseDate(day); err != nil {
+			return o, common.AsError(err).WithParameter(name)
+		}
+	}
+
+	if len(o.ValidFrom) > 0 && len(o.ValidTo) > 0 && o.ValidFrom > o.ValidTo {
+		return o, common.NewError(common.ErrInvalidParameter,
+			"Validity of override ends on %s before it starts on %s.", o.ValidTo,
+			o.ValidFrom).WithParameter("validTo")
+	}
+
+	return o, nil
+}
+
+// Returns true if override applies to rates of given base currency on given day (YYYY-MM-DD) for
+// given tenant
+func (o Override) appliesTo(base, day, tenant string) bool {
+	return o.From == base && (o.Tenant == "" || o.Tenant == tenant) &&
+		(o.ValidFrom == "" || o.ValidFrom <= day) && (o.ValidTo == "" || day <= o.ValidTo)
+}
+
+// Returns true if override takes precedence over given one. Overrides scoped to a tenant win over
+// global ones, then the ones that started to apply later.
+func (o Override) precedes(other Override) bool {
+	if (o.Tenant == "") != (other.Tenant == "") {
+		return o.Tenant != ""
+	}
+
+	if o.ValidFrom != other.ValidFrom {
+		return o.ValidFrom > other.ValidFrom
+	}
+
+	return o.ID < other.ID
+}
+
+// Sorts overrides by their ID
+type byID []Override
+
+func (b byID) Len() int           { return len(b) }
+func (b byID) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byID) Less(i, j int) bool { return b[i].ID < b[j].ID }
+
+// OverrideStore persists overrides managed through admin api
+type OverrideStore interface {
+	// List returns all overrides sorted by their ID
+	List() []Override
+
+	// Get returns override with given ID
+	Get(id string) (Override, error)
+
+	// Save creates override or replaces the one with the same ID. ID is assigned if empty.
+	Save(override Override) (Override, error)
+
+	// Delete removes override with given ID
+	Delete(id string) error
+}
+
+// FileOverrideStore is an OverrideStore kept in a single JSON file on disk. Whole file is
+// rewritten on every change and loaded into memory on open.
+type FileOverrideStore struct {
+	path string
+
+	mu        *sync.RWMutex
+	overrides map[string]Override
+}
+
+// List returns all overrides sorted by their ID
+func (f FileOverrideStore) List() []Override {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.sorted()
+}
+
+// Get returns override with given ID
+func (f FileOverrideStore) Get(id string) (Override, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	override, exists := f.overrides[id]
+	if !exists {
+		return Override{}, common.NewError(common.ErrNotFound, "Override %s does not exist.",
+			id).WithParameter("id")
+	}
+
+	return override, nil
+}
+
+// Save validates override and writes it to the store file
+func (f FileOverrideStore) Save(override Override) (Override, error) {
+	override, err := override.Normalize()
+	if err != nil {
+		return Override{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(override.ID) == 0 {
+		if override.ID, err = newOverrideID(); err != nil {
+			return Override{}, err
+		}
+	}
+
+	previous, existed := f.overrides[override.ID]
+	f.overrides[override.ID] = override
+	if err := f.write(); err != nil {
+		if existed {
+			f.overrides[override.ID] = previous
+		} else {
+			delete(f.overrides, override.ID)
+		}
+
+		return Override{}, err
+	}
+
+	return override, nil
+}
+
+// Delete removes override from the store file
+func (f FileOverrideStore) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	override, exists := f.overrides[id]
+	if !exists {
+		return common.NewError(common.ErrNotFound, "Override %s does not exist.",
+			id).WithParameter("id")
+	}
+
+	delete(f.overrides, id)
+	if err := f.write(); err != nil {
+		f.overrides[id] = override
+		return err
+	}
+
+	return nil
+}
+
+// Returns overrides sorted by their ID. Has to be called with mutex held.
+func (f FileOverrideStore) sorted() []Override {
+	overrides := make([]Override, 0, len(f.overrides))
+	for _, override := range f.overrides {
+		overrides = append(overrides, override)
+	}
+
+	sort.Sort(byID(overrides))
+	return overrides
+}
+
+// Replaces store file with current overrides. Has to be called with mutex held.
+func (f FileOverrideStore) write() error {
+	content, err := json.MarshalIndent(f.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path))
+	if err != nil {
+		log.Printf("Could not write overrides to %s: %s", f.path, err)
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Loads overrides from the store file, if it exists
+func (f FileOverrideStore) load() error {
+	content, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var overrides []Override
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return fmt.Errorf("Override store %s is corrupted: %s", f.path, err)
+	}
+
+	for _, override := range overrides {
+		if override, err = override.Normalize(); err != nil {
+			return fmt.Errorf("Override store %s contains invalid override %s: %s", f.path,
+				override.ID, err)
+		}
+
+		f.overrides[override.ID] = override
+	}
+
+	return nil
+}
+
+// Returns random identifier of a new override
+func newOverrideID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// OpenFileOverrideStore opens override store kept in given file. File is created on first save if
+// it does not exist.
+func OpenFileOverrideStore(path string) (FileOverrideStore, error) {
+	store := FileOverrideStore{path: path, mu: new(sync.RWMutex),
+		overrides: make(map[string]Override)}
+
+	if err := store.load(); err != nil {
+		return FileOverrideStore{}, err
+	}
+
+	return store, nil
+}
+
+// OverridingProvider wraps any provider and replaces rates it returns with overrides applying to
+// the requested day and tenant. Rates of overridden pairs are served even if wrapped provider does
+// not publish them. Implements ConverterProvider and TimeSeriesProvider interfaces.
+type OverridingProvider struct {
+	provider  ConverterProvider
+	overrides OverrideStore
+
+	// Returns current time. Replaced in tests.
+	now func() time.Time
+}
+
+// Name returns name of the wrapped provider
+func (o OverridingProvider) Name() string {
+	return o.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (o OverridingProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := o.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of the wrapped provider with overrides applied. Overrides of the
+// requested date apply, or of the current day if latest rates are requested.
+func (o OverridingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	rates, err := o.provider.Rates(ctx, base, symbols, date)
+	if err != nil {
+		return nil, err
+	}
+
+	day := o.now().UTC().Format(DateFormat)
+	if !date.IsZero() {
+		day = date.Format(DateFormat)
+	}
+
+	return o.apply(ctx, rates, base, symbols, day), nil
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+// with overrides of every published day applied
+func (o OverridingProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	series, err := TimeSeries(ctx, o.provider, base, symbols, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]*ExchangeRates, 0, len(series))
+	for _, rates := range series {
+		applied = append(applied, o.apply(ctx, rates, base, symbols, rates.Date))
+	}
+
+	return applied, nil
+}
+
+// Returns given rates with overrides applying to given day (YYYY-MM-DD) and tenant of the request
+func (o OverridingProvider) apply(ctx context.Context, rates *ExchangeRates, base string,
+	symbols []string, day string) *ExchangeRates {
+
+	requested := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		requested[symbol] = true
+	}
+
+	applied := make(map[string]Override)
+	tenant := TenantFromContext(ctx)
+	for _, override := range o.overrides.List() {
+		if !override.appliesTo(base, day, tenant) ||
+			(len(symbols) > 0 && !requested[override.To]) {
+			continue
+		}
+
+		if current, exists := applied[override.To]; !exists || override.precedes(current) {
+			applied[override.To] = override
+		}
+	}
+
+	if len(applied) == 0 {
+		return rates
+	}
+
+	result := *rates
+	result.Rates = make(Rates, len(rates.Rates)+len(applied))
+	for symbol, rate := range rates.Rates {
+		result.Rates[symbol] = rate
+	}
+
+	// Overridden rates were not aggregated out of rates of several providers
+	if len(rates.Aggregations) > 0 {
+		result.Aggregations = make(Aggregations, len(rates.Aggregations))
+		for symbol, aggregation := range rates.Aggregations {
+			if _, exists := applied[symbol]; !exists {
+				result.Aggregations[symbol] = aggregation
+			}
+		}
+	}
+
+	result.Overridden = make([]string, 0, len(applied))
+	for symbol, override := range applied {
+		result.Rates[symbol] = override.Rate
+		result.Overridden = append(result.Overridden, symbol)
+	}
+
+	sort.Strings(result.Overridden)
+	return &result
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (o OverridingProvider) Capabilities() Capabilities {
+	return o.provider.Capabilities()
+}
+
+// NewOverridingProvider returns provider applying overrides of given store to rates of given
+// provider
+func NewOverridingProvider(provider ConverterProvider,
+	overrides OverrideStore) OverridingProvider {
+
+	return OverridingProvider{provider: provider, overrides: overrides, now: time.Now}
+}
@@ -0,0 +1,169 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Opens override store in a temporary directory
+func openOverrideStore(t *testing.T) (FileOverrideStore, string) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenFileOverrideStore(filepath.Join(dir, "overrides.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, dir
+}
+
+func TestFileOverrideStore(t *testing.T) {
+	store, dir := openOverrideStore(t)
+	defer os.RemoveAll(dir)
+
+	created, err := store.Save(Override{From: "eur", To: "pln", Rate: common.MustParseDecimal("4.3"),
+		Tenant: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created.ID) == 0 || created.From != "EUR" || created.To != "PLN" {
+		t.Errorf("Save() == \ngot: %v, \nexpected normalized override with ID", created)
+	}
+
+	created.Rate = common.MustParseDecimal("4.35")
+	if _, err := store.Save(created); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := store.Save(Override{From: "USD", To: "PLN", Rate: common.NewDecimal(4, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete(other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenFileOverrideStore(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := reopened.List(); len(actual) != 1 || actual[0].Rate.String() != "4.35" {
+		t.Errorf("List() == \ngot: %v, \nexpected %v", actual, created)
+	}
+
+	for _, err := range []error{reopened.Delete(other.ID), func() error {
+		_, err := reopened.Get(other.ID)
+		return err
+	}()} {
+		if common.AsError(err).Code != common.ErrNotFound {
+			t.Errorf("Delete/Get(%s) == \ngot: %v, \nexpected %s", other.ID, err,
+				common.ErrNotFound)
+		}
+	}
+}
+
+func TestOverrideNormalize(t *testing.T) {
+	rate := common.NewDecimal(1, 0)
+	cases := []struct {
+		override  Override
+		parameter string
+	}{
+		{Override{From: "XYZ", To: "PLN", Rate: rate}, "from"},
+		{Override{From: "EUR", To: "eur", Rate: rate}, "to"},
+		{Override{From: "EUR", To: "PLN"}, "rate"},
+		{Override{From: "EUR", To: "PLN", Rate: rate, ValidFrom: "2020-13-01"}, "validFrom"},
+		{Override{From: "EUR", To: "PLN", Rate: rate, ValidFrom: "2020-02-01",
+			ValidTo: "2020-01-01"}, "validTo"},
+	}
+
+	for _, c := range cases {
+		_, err := c.override.Normalize()
+		if actual := common.AsError(err); err == nil || actual.Parameter != c.parameter {
+			t.Errorf("Normalize(%v) == \ngot: %v, \nexpected error of %s", c.override, err,
+				c.parameter)
+		}
+	}
+}
+
+func TestOverridingProviderRates(t *testing.T) {
+	store, dir := openOverrideStore(t)
+	defer os.RemoveAll(dir)
+
+	for _, override := range []Override{
+		{From: "PLN", To: "EUR", Rate: common.MustParseDecimal("0.2")},
+		{From: "PLN", To: "EUR", Rate: common.MustParseDecimal("0.21"), ValidFrom: "2016-10-01",
+			ValidTo: "2016-10-31"},
+		{From: "PLN", To: "EUR", Rate: common.MustParseDecimal("0.22"), Tenant: "acme"},
+		{From: "PLN", To: "KES", Rate: common.MustParseDecimal("0.04"), Tenant: "acme"},
+	} {
+		if _, err := store.Save(override); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := NewOverridingProvider(NewLocalProvider(""), store)
+	provider.now = func() time.Time { return time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC) }
+	historical := time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		tenant     string
+		symbols    []string
+		date       time.Time
+		expected   map[string]string
+		overridden []string
+	}{
+		{"", []string{"EUR", "USD"}, time.Time{}, map[string]string{"EUR": "0.2",
+			"USD": "0.25292"}, []string{"EUR"}},
+		{"", []string{"EUR"}, historical, map[string]string{"EUR": "0.21"}, []string{"EUR"}},
+		{"acme", []string{"EUR"}, historical, map[string]string{"EUR": "0.22"}, []string{"EUR"}},
+		{"acme", []string{"EUR", "KES"}, time.Time{}, map[string]string{"EUR": "0.22",
+			"KES": "0.04"}, []string{"EUR", "KES"}},
+		{"other", []string{"USD"}, time.Time{}, map[string]string{"USD": "0.25292"}, nil},
+	}
+
+	for _, c := range cases {
+		ctx := WithTenant(context.Background(), c.tenant)
+		rates, err := provider.Rates(ctx, "PLN", c.symbols, c.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := make(map[string]string, len(rates.Rates))
+		for symbol, rate := range rates.Rates {
+			actual[symbol] = rate.String()
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) ||
+			!reflect.DeepEqual(rates.Overridden, c.overridden) {
+			t.Errorf("Rates(%s, %s, %v) == \ngot: %v %v, \nexpected %v %v", c.tenant,
+				strings.Join(c.symbols, ","), c.date, actual, rates.Overridden, c.expected,
+				c.overridden)
+		}
+	}
+}
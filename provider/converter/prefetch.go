@@ -0,0 +1,259 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Number of scheduled refreshes after which prefetched rates that could not be refreshed are no
+// longer served
+const prefetchMaxAgeRefreshes = 3
+
+// Schedule of background refreshes. Refresh is run after every interval and at every daily time,
+// whichever comes first.
+type Schedule struct {
+	// Interval between refreshes, ignored if not positive
+	Interval time.Duration
+	// Times - daily times of refreshes in HH:MM format (UTC)
+	Times []string
+}
+
+// Next returns time of the first refresh after given time. Zero time is returned if schedule
+// is empty.
+func (s Schedule) Next(after time.Time) time.Time {
+	var next time.Time
+	if s.Interval > 0 {
+		next = after.Add(s.Interval)
+	}
+
+	after = after.UTC()
+	for _, value := range s.Times {
+		clock, err := ParseClock(value)
+		if err != nil {
+			continue
+		}
+
+		day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+		at := day.Add(clock)
+		if !at.After(after) {
+			at = at.AddDate(0, 0, 1)
+		}
+
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+
+	return next
+}
+
+// ParseClock parses daily time in HH:MM format and returns its offset from midnight
+func ParseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time '%s'. Expected format: HH:MM.", value)
+	}
+
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// RefreshStatus describes outcome of background refreshes of a provider
+type RefreshStatus struct {
+	// LastRun - time when the last refresh finished, zero if there was none
+	LastRun time.Time
+	// LastSuccess - time of the last refresh that fetched every base currency
+	LastSuccess time.Time
+	// NextRun - time of the next scheduled refresh
+	NextRun time.Time
+	// Bases - number of base currencies whose rates are prefetched
+	Bases int
+	// LastError - errors of the last refresh, empty if it succeeded
+	LastError string
+}
+
+// RefreshReporter is implemented by providers refreshed in the background
+type RefreshReporter interface {
+	// RefreshStatus returns outcome of background refreshes
+	RefreshStatus() RefreshStatus
+}
+
+// PrefetchingProvider periodically fetches latest exchange rates tables of given base currencies
+// in the background and serves requests for latest rates of those currencies from them. Tables
+// that failed to refresh for several scheduled refreshes are not served anymore. Other requests
+// are passed to the wrapped provider. Implements ConverterProvider, TimeSeriesProvider and
+// RefreshReporter interfaces.
+type PrefetchingProvider struct {
+	provider ConverterProvider
+	bases    []string
+	schedule Schedule
+
+	mu      *sync.RWMutex
+	tables  map[string]*ExchangeRates
+	fetched map[string]time.Time
+	status  *RefreshStatus
+	now     func() time.Time
+}
+
+// Name returns name of the wrapped provider
+func (p PrefetchingProvider) Name() string {
+	return p.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (p PrefetchingProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := p.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns prefetched rates of given base currency if latest rates or rates of the prefetched
+// day are requested and they are not stale, otherwise rates of the wrapped provider. Prefetched
+// rates fetched through cache are reported as cache hits.
+func (p PrefetchingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	p.mu.RLock()
+	table, exists := p.tables[strings.ToUpper(base)]
+	fetched := p.fetched[strings.ToUpper(base)]
+	p.mu.RUnlock()
+
+	if exists && !p.isStale(fetched) &&
+		(date.IsZero() || date.Format(DateFormat) == table.Date) {
+		rates := *table
+		rates.Rates = selectRates(table.Rates, table.Base, symbols)
+		if rates.Cache != "" {
+			rates.Cache = CacheHit
+		}
+
+		return &rates, nil
+	}
+
+	return p.provider.Rates(ctx, base, symbols, date)
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+func (p PrefetchingProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	return TimeSeries(ctx, p.provider, base, symbols, start, end)
+}
+
+// Capabilities returns currencies served by the wrapped provider
+func (p PrefetchingProvider) Capabilities() Capabilities {
+	return p.provider.Capabilities()
+}
+
+// Refresh fetches latest rates of every base currency, replacing them in cache of the wrapped
+// provider if there is one. Rates of currencies that could not be fetched are kept from the
+// previous refresh.
+func (p PrefetchingProvider) Refresh(ctx context.Context) error {
+	failures := make([]string, 0)
+	for _, base := range p.bases {
+		table, err := p.provider.Rates(withRefresh(ctx), base, nil, time.Time{})
+		if err != nil {
+			log.Printf("Could not refresh %s rates of %s provider: %s", base, p.Name(), err)
+			failures = append(failures, fmt.Sprintf("%s: %s", base, common.AsError(err).Message))
+			continue
+		}
+
+		p.mu.Lock()
+		p.tables[base] = table
+		p.fetched[base] = p.now()
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.LastRun = p.now()
+	p.status.Bases = len(p.tables)
+	p.status.LastError = strings.Join(failures, "; ")
+	if len(failures) > 0 {
+		return fmt.Errorf("Could not refresh rates of %s provider: %s", p.Name(),
+			p.status.LastError)
+	}
+
+	p.status.LastSuccess = p.status.LastRun
+	return nil
+}
+
+// Run refreshes rates right away and then according to the schedule until given context is done
+func (p PrefetchingProvider) Run(ctx context.Context) {
+	for {
+		p.Refresh(ctx)
+
+		next := p.schedule.Next(p.now())
+		p.mu.Lock()
+		p.status.NextRun = next
+		p.mu.Unlock()
+
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(p.now())):
+		}
+	}
+}
+
+// Returns true if table fetched at given time missed prefetchMaxAgeRefreshes scheduled refreshes
+func (p PrefetchingProvider) isStale(fetched time.Time) bool {
+	next := p.schedule.Next(fetched)
+	if next.IsZero() {
+		return false
+	}
+
+	return p.now().Sub(fetched) > prefetchMaxAgeRefreshes*next.Sub(fetched)
+}
+
+// RefreshStatus returns outcome of background refreshes
+func (p PrefetchingProvider) RefreshStatus() RefreshStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return *p.status
+}
+
+// NewPrefetchingProvider returns provider prefetching latest rates of given base currencies from
+// given provider according to given schedule. Refreshes are started with Run.
+func NewPrefetchingProvider(provider ConverterProvider, bases []string,
+	schedule Schedule) PrefetchingProvider {
+
+	normalized := make([]string, 0, len(bases))
+	for _, base := range bases {
+		if currency, err := common.NormalizeCurrency(base); err == nil {
+			normalized = append(normalized, currency)
+		}
+	}
+
+	return PrefetchingProvider{provider: provider, bases: normalized, schedule: schedule,
+		mu: new(sync.RWMutex), tables: make(map[string]*ExchangeRates),
+		fetched: make(map[string]time.Time), status: new(RefreshStatus), now: time.Now}
+}
@@ -0,0 +1,154 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	after := time.Date(2016, 10, 31, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		schedule Schedule
+		expected time.Time
+	}{
+		{Schedule{}, time.Time{}},
+		{Schedule{Interval: time.Hour}, after.Add(time.Hour)},
+		{Schedule{Times: []string{"16:30"}}, time.Date(2016, 10, 31, 16, 30, 0, 0, time.UTC)},
+		{Schedule{Times: []string{"06:00", "15:00"}},
+			time.Date(2016, 11, 1, 6, 0, 0, 0, time.UTC)},
+		{Schedule{Interval: 30 * time.Minute, Times: []string{"15:10", "invalid"}},
+			time.Date(2016, 10, 31, 15, 10, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		if got := c.schedule.Next(after); !got.Equal(c.expected) {
+			t.Errorf("Schedule(%v).Next(%s) == \ngot: %s, \nexpected %s", c.schedule, after, got,
+				c.expected)
+		}
+	}
+}
+
+func TestPrefetchingProvider(t *testing.T) {
+	calls := new(int)
+	provider := NewPrefetchingProvider(dailyProvider{calls}, []string{"pln", "EUR"}, Schedule{})
+	if err := provider.Refresh(context.Background()); err != nil || *calls != 2 {
+		t.Fatalf("Refresh() == \ngot: %v (%d calls), \nexpected 2 calls", err, *calls)
+	}
+
+	cases := []struct {
+		base          string
+		date          time.Time
+		expectedCalls int
+	}{
+		{"PLN", time.Time{}, 2},
+		{"eur", time.Time{}, 2},
+		{"USD", time.Time{}, 3},
+		{"PLN", time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC), 4},
+	}
+
+	for _, c := range cases {
+		rates, err := provider.Rates(context.Background(), c.base, []string{"USD"}, c.date)
+		if err != nil || len(rates.Rates) != 1 || *calls != c.expectedCalls {
+			t.Errorf("PrefetchingProvider.Rates(%s, %s) == \ngot: %v, %v (%d calls), "+
+				"\nexpected %d calls", c.base, c.date, rates, err, *calls, c.expectedCalls)
+		}
+	}
+
+	status := provider.RefreshStatus()
+	if status.Bases != 2 || status.LastError != "" || status.LastSuccess.IsZero() {
+		t.Errorf("RefreshStatus() == \ngot: %v, \nexpected 2 bases without errors", status)
+	}
+}
+
+func TestPrefetchingProviderThroughCache(t *testing.T) {
+	calls := new(int)
+	cache := NewCachingProvider(dailyProvider{calls}, time.Hour)
+	provider := NewPrefetchingProvider(cache, []string{"PLN"}, Schedule{})
+
+	cases := []struct {
+		provider       ConverterProvider
+		refresh        bool
+		expectedStatus string
+		expectedCalls  int
+	}{
+		{cache, false, CacheMiss, 1},
+		{provider, true, CacheHit, 2},
+		{cache, false, CacheHit, 2},
+		{provider, true, CacheHit, 3},
+	}
+
+	for _, c := range cases {
+		if c.refresh {
+			if err := provider.Refresh(context.Background()); err != nil {
+				t.Fatalf("Refresh() returned error: %v", err)
+			}
+		}
+
+		rates, err := c.provider.Rates(context.Background(), "PLN", []string{"USD"}, time.Time{})
+		if err != nil || rates.Cache != c.expectedStatus || *calls != c.expectedCalls {
+			t.Errorf("%T.Rates(PLN) == \ngot: %v, %v (%d calls), \nexpected %s (%d calls)",
+				c.provider, rates, err, *calls, c.expectedStatus, c.expectedCalls)
+		}
+	}
+}
+
+func TestPrefetchingProviderMaxAge(t *testing.T) {
+	calls := new(int)
+	provider := NewPrefetchingProvider(dailyProvider{calls}, []string{"PLN"},
+		Schedule{Interval: time.Minute})
+	now := time.Date(2016, 10, 31, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+	if err := provider.Refresh(context.Background()); err != nil || *calls != 1 {
+		t.Fatalf("Refresh() == \ngot: %v (%d calls), \nexpected 1 call", err, *calls)
+	}
+
+	cases := []struct {
+		after         time.Duration
+		expectedCalls int
+	}{
+		{time.Minute, 1},
+		{3 * time.Minute, 1},
+		{3*time.Minute + time.Second, 2},
+	}
+
+	for _, c := range cases {
+		now = time.Date(2016, 10, 31, 12, 0, 0, 0, time.UTC).Add(c.after)
+		if _, err := provider.Rates(context.Background(), "PLN", nil, time.Time{}); err != nil ||
+			*calls != c.expectedCalls {
+			t.Errorf("PrefetchingProvider.Rates() after %s == \ngot: %v (%d calls), "+
+				"\nexpected %d calls", c.after, err, *calls, c.expectedCalls)
+		}
+	}
+}
+
+func TestPrefetchingProviderFailure(t *testing.T) {
+	failure := errors.New("Unavailable.")
+	provider := NewPrefetchingProvider(failingProvider{dailyProvider{new(int)}, "failing",
+		failure}, []string{"PLN"}, Schedule{})
+	provider.Run(context.Background())
+
+	status := provider.RefreshStatus()
+	if status.LastRun.IsZero() || !status.LastSuccess.IsZero() || status.Bases != 0 ||
+		!strings.Contains(status.LastError, "PLN: Internal error.") || !status.NextRun.IsZero() {
+		t.Errorf("RefreshStatus() == \ngot: %v, \nexpected failed refresh of PLN", status)
+	}
+
+	if _, err := provider.Rates(context.Background(), "PLN", nil, time.Time{}); err != failure {
+		t.Errorf("PrefetchingProvider.Rates(PLN) == \ngot: %v, \nexpected %v", err, failure)
+	}
+}
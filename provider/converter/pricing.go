@@ -0,0 +1,256 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Wildcard matches any currency in markup rules
+const Wildcard = "*"
+
+var hundred = common.NewDecimal(100, 0)
+
+// Price describes how converted amount of a single currency was priced
+type Price struct {
+	// MidRate - exchange rate served by provider
+	MidRate common.Decimal `json:"midRate" xml:"midRate"`
+
+	// AppliedRate - exchange rate after spread of matching markup rule
+	AppliedRate common.Decimal `json:"appliedRate" xml:"appliedRate"`
+
+	// Fee charged in request currency
+	Fee common.Decimal `json:"fee" xml:"fee"`
+
+	// Net - amount received in target currency after fee. In inverse direction total amount of
+	// request currency that has to be paid, fee included.
+	Net common.Decimal `json:"net" xml:"net"`
+}
+
+// Prices maps target currencies to their prices
+type Prices map[string]Price
+
+// MarshalXML implements xml.Marshaler. Every price is marshalled as an element named after its
+// currency.
+func (p Prices) MarshalXML(enc *xml.Encoder, startElem xml.StartElement) error {
+	if err := enc.EncodeToken(startElem); err != nil {
+		return err
+	}
+
+	currencies := make([]string, 0, len(p))
+	for currency := range p {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		elem := xml.StartElement{Name: xml.Name{Local: currency}}
+		if err := enc.EncodeElement(p[currency], elem); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(startElem.End())
+}
+
+// MarkupRule is a spread applied to exchange rates of matching currency pairs. Empty or wildcard
+// currency matches any currency.
+type MarkupRule struct {
+	// From - currency of converted amount
+	From string `json:"from"`
+	// To - target currency
+	To string `json:"to"`
+	// Spread - percent of mid rate kept as margin
+	Spread common.Decimal `json:"spread"`
+}
+
+// Returns true if rule applies to given pair of currencies
+func (m MarkupRule) matches(from, to string) bool {
+	return matchesCurrency(m.From, from) && matchesCurrency(m.To, to)
+}
+
+// Returns how specific rule is. Rules naming a target currency win over rules naming a source one.
+func (m MarkupRule) specificity() int {
+	result := 0
+	if !matchesCurrency(m.From, "") {
+		result++
+	}
+
+	if !matchesCurrency(m.To, "") {
+		result += 2
+	}
+
+	return result
+}
+
+func matchesCurrency(pattern, currency string) bool {
+	return pattern == "" || pattern == Wildcard || strings.EqualFold(pattern, currency)
+}
+
+// FeeProfile is a fee charged in currency of converted amount. Fee is the fixed part plus given
+// percent of amount, but not less than the minimum.
+type FeeProfile struct {
+	// Percent of amount charged
+	Percent common.Decimal `json:"percent"`
+	// Fixed part of the fee
+	Fixed common.Decimal `json:"fixed"`
+	// Min - minimal fee
+	Min common.Decimal `json:"min"`
+}
+
+// Returns fee charged for given amount rounded to the minor unit of its currency
+func (f *FeeProfile) fee(amount common.Decimal, currency string,
+	rounding common.RoundingMode) common.Decimal {
+
+	if f == nil {
+		return common.Decimal{}
+	}
+
+	fee := f.Fixed.Add(percentOf(amount, f.Percent))
+	if fee.Cmp(f.Min) < 0 {
+		fee = f.Min
+	}
+
+	return common.RoundMoney(fee, currency, rounding)
+}
+
+// PricingConfig describes markups and fee profiles applied to conversions
+type PricingConfig struct {
+	// Markups - spreads applied to exchange rates. The most specific matching rule is used.
+	Markups []MarkupRule `json:"markups"`
+	// FeeProfiles - fee profiles selected by requests
+	FeeProfiles map[string]FeeProfile `json:"feeProfiles"`
+}
+
+// Pricer applies markups and fees to converted amounts
+type Pricer struct {
+	config PricingConfig
+}
+
+// FeeProfile returns fee profile with given name. Nil profile is returned for empty name.
+func (p Pricer) FeeProfile(name string) (*FeeProfile, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+
+	profile, exists := p.config.FeeProfiles[name]
+	if !exists {
+		return nil, common.NewError(common.ErrInvalidParameter, "Fee profile %s does not exist.",
+			name).WithParameter("fees")
+	}
+
+	return &profile, nil
+}
+
+// Apply prices every converted amount of response with spread of matching markup rule and given
+// fee profile. Converted amounts are replaced with net amounts. Response is left untouched if there
+// are no markup rules and no fee profile.
+func (p Pricer) Apply(response *ConverterResponse, fees *FeeProfile) {
+	if len(p.config.Markups) == 0 && fees == nil {
+		return
+	}
+
+	prices := make(Prices, len(response.Rates))
+	converted := make(ConvertedRates, len(response.Rates))
+	for currency, mid := range response.Rates {
+		applied := mid
+		if currency != response.Currency {
+			applied = mid.Sub(percentOf(mid, p.spread(response.Currency, currency)))
+		}
+
+		if applied.Sign() <= 0 {
+			continue
+		}
+
+		var fee, net common.Decimal
+		if response.Direction == DirectionInverse {
+			fee, net = quoteWithFee(response.Amount, response.Currency, currency, applied, fees)
+		} else {
+			fee = fees.fee(response.Amount, response.Currency, response.Rounding)
+			if fee.Cmp(response.Amount) > 0 {
+				fee = response.Amount
+			}
+
+			net = common.RoundMoney(response.Amount.Sub(fee).Mul(applied), currency,
+				response.Rounding)
+		}
+
+		prices[currency] = Price{MidRate: mid, AppliedRate: applied, Fee: fee, Net: net}
+		converted[currency] = net
+	}
+
+	response.Pricing = prices
+	response.Converted = converted
+}
+
+// Returns spread of the most specific markup rule matching given pair, zero if none matches
+func (p Pricer) spread(from, to string) common.Decimal {
+	var match *MarkupRule
+	for i, rule := range p.config.Markups {
+		if rule.matches(from, to) && (match == nil || rule.specificity() > match.specificity()) {
+			match = &p.config.Markups[i]
+		}
+	}
+
+	if match == nil {
+		return common.Decimal{}
+	}
+
+	return match.Spread
+}
+
+// Returns fee and total amount of base currency that has to be paid to receive given amount of
+// target currency with given rate after the fee is charged. Total is rounded up to the minor unit.
+func quoteWithFee(amount common.Decimal, base, target string, rate common.Decimal,
+	fees *FeeProfile) (common.Decimal, common.Decimal) {
+
+	units := common.MinorUnits(base)
+	required := common.RoundMoney(amount, target, common.Ceiling).Quo(rate, units, common.Ceiling)
+	if fees == nil {
+		return common.Decimal{}, required
+	}
+
+	// Percent fee is charged from the total, so required amount is only a part of it. Minimal fee
+	// is charged instead if it is higher.
+	keep := hundred.Sub(fees.Percent)
+	total := required.Add(fees.Fixed).Mul(hundred).Quo(keep, units, common.Ceiling)
+	withMin := common.RoundMoney(required.Add(fees.Min), base, common.Ceiling)
+	if withMin.Cmp(total) > 0 {
+		total = withMin
+	}
+
+	// Fee rounded up to the minor unit may still take a unit or two more than computed
+	for {
+		missing := required.Sub(total.Sub(fees.fee(total, base, common.Ceiling)))
+		if missing.Sign() <= 0 {
+			break
+		}
+		total = total.Add(missing.Mul(hundred).Quo(keep, units, common.Ceiling))
+	}
+
+	return fees.fee(total, base, common.Ceiling), total
+}
+
+// Returns given percent of value
+func percentOf(value, percent common.Decimal) common.Decimal {
+	return value.Mul(percent).Quo(hundred, value.Scale()+percent.Scale()+2, common.HalfUp)
+}
+
+// NewPricer returns pricer applying given markups and fee profiles
+func NewPricer(config PricingConfig) Pricer {
+	return Pricer{config: config}
+}
@@ -0,0 +1,146 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/floreks/go-currency/common"
+)
+
+var testPricer = NewPricer(PricingConfig{
+	Markups: []MarkupRule{
+		{From: Wildcard, To: Wildcard, Spread: common.MustParseDecimal("1")},
+		{From: "PLN", To: "EUR", Spread: common.MustParseDecimal("0.5")},
+		{To: "USD", Spread: common.MustParseDecimal("2")},
+		{From: "PLN", Spread: common.MustParseDecimal("1.5")},
+	},
+	FeeProfiles: map[string]FeeProfile{
+		"retail": {Percent: common.MustParseDecimal("1"), Fixed: common.MustParseDecimal("2"),
+			Min: common.MustParseDecimal("5")},
+		"business": {Percent: common.MustParseDecimal("2"), Fixed: common.MustParseDecimal("1")},
+	},
+})
+
+func pricedResponse(amount string, direction string) *ConverterResponse {
+	return &ConverterResponse{Amount: common.MustParseDecimal(amount), Currency: "PLN",
+		Direction: direction, Rounding: common.HalfUp, Rates: ConvertedRates{
+			"EUR": common.MustParseDecimal("0.23106"),
+			"USD": common.MustParseDecimal("0.25292"),
+			"PLN": common.NewDecimal(1, 0),
+			"GBP": common.MustParseDecimal("0.20807"),
+		}}
+}
+
+func TestPricerApply(t *testing.T) {
+	cases := []struct {
+		direction string
+		amount    string
+		fees      string
+		currency  string
+		expected  Price
+	}{
+		{DirectionForward, "100", "retail", "EUR", Price{common.MustParseDecimal("0.23106"),
+			common.MustParseDecimal("0.2299047"), common.MustParseDecimal("5.00"),
+			common.MustParseDecimal("21.84")}},
+		{DirectionForward, "100", "retail", "USD", Price{common.MustParseDecimal("0.25292"),
+			common.MustParseDecimal("0.2478616"), common.MustParseDecimal("5.00"),
+			common.MustParseDecimal("23.55")}},
+		{DirectionForward, "100", "", "PLN", Price{common.NewDecimal(1, 0),
+			common.NewDecimal(1, 0), common.Decimal{}, common.MustParseDecimal("100.00")}},
+		{DirectionForward, "100", "", "GBP", Price{common.MustParseDecimal("0.20807"),
+			common.MustParseDecimal("0.20494895"), common.Decimal{},
+			common.MustParseDecimal("20.49")}},
+		{DirectionInverse, "50", "retail", "EUR", Price{common.MustParseDecimal("0.23106"),
+			common.MustParseDecimal("0.2299047"), common.MustParseDecimal("5.00"),
+			common.MustParseDecimal("222.49")}},
+		{DirectionInverse, "50", "business", "EUR", Price{common.MustParseDecimal("0.23106"),
+			common.MustParseDecimal("0.2299047"), common.MustParseDecimal("5.46"),
+			common.MustParseDecimal("222.95")}},
+		{DirectionInverse, "50", "", "EUR", Price{common.MustParseDecimal("0.23106"),
+			common.MustParseDecimal("0.2299047"), common.Decimal{},
+			common.MustParseDecimal("217.49")}},
+	}
+
+	for _, c := range cases {
+		fees, err := testPricer.FeeProfile(c.fees)
+		if err != nil {
+			t.Fatalf("FeeProfile(%s) == %v", c.fees, err)
+		}
+
+		response := pricedResponse(c.amount, c.direction)
+		testPricer.Apply(response, fees)
+		got := response.Pricing[c.currency]
+		if !got.MidRate.Equal(c.expected.MidRate) ||
+			!got.AppliedRate.Equal(c.expected.AppliedRate) || !got.Fee.Equal(c.expected.Fee) ||
+			!got.Net.Equal(c.expected.Net) || !response.Converted[c.currency].Equal(got.Net) {
+			t.Errorf("Apply(%s %s %s, %s) == \ngot: %v, \nexpected %v", c.direction, c.amount,
+				c.currency, c.fees, got, c.expected)
+		}
+	}
+}
+
+func TestPricerUnknownFeeProfile(t *testing.T) {
+	_, err := testPricer.FeeProfile("unknown")
+	if common.AsError(err).Code != common.ErrInvalidParameter {
+		t.Errorf("FeeProfile(unknown) == \ngot: %v, \nexpected %s", err,
+			common.ErrInvalidParameter)
+	}
+}
+
+func TestPricerWithoutRules(t *testing.T) {
+	response := pricedResponse("100", DirectionForward)
+	response.Converted = ConvertedRates{"EUR": common.MustParseDecimal("23.11")}
+	NewPricer(PricingConfig{}).Apply(response, nil)
+	if response.Pricing != nil || len(response.Converted) != 1 {
+		t.Errorf("Apply() without rules changed response: %v", response)
+	}
+}
+
+func TestQuoteWithFee(t *testing.T) {
+	cases := []struct {
+		amount        string
+		fees          FeeProfile
+		expectedFee   string
+		expectedTotal string
+	}{
+		{"50", FeeProfile{Percent: common.MustParseDecimal("1"),
+			Fixed: common.MustParseDecimal("2"), Min: common.MustParseDecimal("5")},
+			"5.00", "221.40"},
+		{"50", FeeProfile{Percent: common.MustParseDecimal("2"),
+			Fixed: common.MustParseDecimal("1")}, "5.44", "221.84"},
+		{"1000000", FeeProfile{Min: common.MustParseDecimal("100000")},
+			"100000.00", "4427880.21"},
+		{"1000000", FeeProfile{Percent: common.MustParseDecimal("99.5")},
+			"861248161.79", "865576042.00"},
+		{"1000000", FeeProfile{Percent: common.MustParseDecimal("0.333"),
+			Fixed: common.MustParseDecimal("0.01")}, "14460.01", "4342340.22"},
+	}
+
+	rate := common.MustParseDecimal("0.23106")
+	unit := common.NewDecimal(1, common.MinorUnits("PLN"))
+	for _, c := range cases {
+		amount := common.MustParseDecimal(c.amount)
+		fee, total := quoteWithFee(amount, "PLN", "EUR", rate, &c.fees)
+
+		// Total has to be the smallest one covering required amount after the fee is charged
+		_, required := quoteWithFee(amount, "PLN", "EUR", rate, nil)
+		less := total.Sub(unit)
+		if fee.String() != c.expectedFee || total.String() != c.expectedTotal ||
+			total.Sub(fee).Cmp(required) < 0 ||
+			less.Sub(c.fees.fee(less, "PLN", common.Ceiling)).Cmp(required) >= 0 {
+			t.Errorf("quoteWithFee(%s, %v) == \ngot: %s %s, \nexpected %s %s", c.amount, c.fees,
+				fee, total, c.expectedFee, c.expectedTotal)
+		}
+	}
+}
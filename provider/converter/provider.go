@@ -13,18 +13,62 @@
 package converter
 
 import (
+	"context"
 	"encoding/xml"
-	"fmt"
+	"strings"
+	"time"
+
+	"github.com/floreks/go-currency/common"
 )
 
 // Supported providers
 const (
-	FixerIO = "fixerio"
-	Local   = "local"
+	FixerIO   = "fixerio"
+	Local     = "local"
+	ECB       = "ecb"
+	Store     = "store"
+	Fallback  = "fallback"
+	CSV       = "csv"
+	Aggregate = "aggregate"
 )
 
+// Rates is an exchange rates map keyed by currency code.
+type Rates map[string]common.Decimal
+
+// ExchangeRates is a table of exchange rates of a single base currency returned by providers.
+type ExchangeRates struct {
+	// Base - base currency
+	Base string
+	// Date - date on which exchange rates were published
+	Date string
+	// Pivot - currency through which rates were derived, empty if rates were published directly
+	Pivot string
+	// Cache - cache status of rates, empty if rates were not served through cache
+	Cache string
+	// Provider - name of the provider that actually served rates, if different than requested
+	Provider string
+	// Failures - providers that failed to serve rates before the one that succeeded
+	Failures ProviderFailures
+	// Overridden - sorted currencies whose rates were replaced by overrides
+	Overridden []string
+	// Aggregations - how rates were combined out of rates of several providers, if they were
+	Aggregations Aggregations
+	// Rates - exchange rates
+	Rates Rates
+}
+
 // ConvertedRates converted rates that will be presented to the user.
-type ConvertedRates map[string]float64
+type ConvertedRates map[string]common.Decimal
+
+// Float64 returns converted rates as floats. Kept only for compatibility, precision might be lost.
+func (c ConvertedRates) Float64() map[string]float64 {
+	result := make(map[string]float64, len(c))
+	for key, value := range c {
+		result[key] = value.Float64()
+	}
+
+	return result
+}
 
 // MarshalXML - marshals convertedRates map into XML
 func (c ConvertedRates) MarshalXML(enc *xml.Encoder, startElem xml.StartElement) error {
@@ -32,7 +76,7 @@ func (c ConvertedRates) MarshalXML(enc *xml.Encoder, startElem xml.StartElement)
 
 	for key, value := range c {
 		t := xml.StartElement{Name: xml.Name{Space: "", Local: key}}
-		tokens = append(tokens, t, xml.CharData(fmt.Sprintf("%v", value)),
+		tokens = append(tokens, t, xml.CharData(value.String()),
 			xml.EndElement{Name: t.Name})
 	}
 
@@ -53,32 +97,272 @@ func (c ConvertedRates) MarshalXML(enc *xml.Encoder, startElem xml.StartElement)
 	return nil
 }
 
+// ConverterRequest describes conversion that should be done by converter provider.
+type ConverterRequest struct {
+	// Amount of money which is a base for exchange rate calculation
+	Amount common.Decimal
+
+	// Currency for which we should calculate exchange rates
+	Currency string
+
+	// Symbols limits conversion to given target currencies. All known currencies are used if empty.
+	Symbols []string
+
+	// Date of exchange rates that should be used. Latest rates are used if zero.
+	Date time.Time
+
+	// Rounding mode used to round converted amounts to the minor unit of each currency
+	Rounding common.RoundingMode
+
+	// Direction of conversion. Amount is converted forward if empty.
+	Direction string
+}
+
 // ConverterResponse is a structure returned by converter providers.
 type ConverterResponse struct {
 	// XMLName needed for correct xml response
 	XMLName xml.Name `json:"-" xml:"ConverterResponse"`
 
 	// Amount of money which is a base for exchange rate calculation
-	Amount float64 `json:"amount" xml:"amount"`
+	Amount common.Decimal `json:"amount" xml:"amount"`
 
 	// Currency for which we should calculate exchange rates
 	Currency string `json:"currency" xml:"currency"`
 
+	// Direction of conversion. In inverse direction amount is given in target currencies and
+	// converted amounts are required amounts of currency.
+	Direction string `json:"direction,omitempty" xml:"direction,omitempty"`
+
+	// Rounding mode applied to converted rates
+	Rounding common.RoundingMode `json:"rounding" xml:"rounding"`
+
+	// Date of exchange rates that were applied
+	Date string `json:"date,omitempty" xml:"date,omitempty"`
+
+	// Derived is true if exchange rates were not published directly but derived through pivot
+	Derived bool `json:"derived" xml:"derived"`
+
+	// Pivot currency through which exchange rates were derived
+	Pivot string `json:"pivot,omitempty" xml:"pivot,omitempty"`
+
+	// Provider that actually served exchange rates when served through fallback provider
+	Provider string `json:"provider,omitempty" xml:"provider,omitempty"`
+
+	// Providers that failed to serve exchange rates before the one that succeeded
+	Failures ProviderFailures `json:"failures,omitempty" xml:"failures,omitempty"`
+
+	// Exchange rates used for conversion
+	Rates ConvertedRates `json:"rates,omitempty" xml:"rates,omitempty"`
+
+	// Currencies whose exchange rates were pinned by overrides
+	Overridden []string `json:"overridden,omitempty" xml:"overridden,omitempty"`
+
+	// Number of providers and spread of their rates when exchange rates were aggregated
+	Aggregation Aggregations `json:"aggregation,omitempty" xml:"aggregation,omitempty"`
+
 	// Converted rates based on given amount and currency
 	Converted ConvertedRates `json:"converted" xml:"converted"`
+
+	// Pricing of converted amounts when markups or fees were applied
+	Pricing Prices `json:"pricing,omitempty" xml:"pricing,omitempty"`
+
+	// Cache status of exchange rates. Reported in response headers only.
+	Cache string `json:"-" xml:"-"`
+}
+
+// AmountFloat64 returns amount as float. Kept only for compatibility, precision might be lost.
+func (c ConverterResponse) AmountFloat64() float64 {
+	return c.Amount.Float64()
+}
+
+// PairResponse is a structure returned for conversion between a single pair of currencies.
+type PairResponse struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"PairResponse"`
+
+	// Amount of money in source currency
+	Amount common.Decimal `json:"amount" xml:"amount"`
+
+	// From - source currency
+	From string `json:"from" xml:"from"`
+
+	// To - target currency
+	To string `json:"to" xml:"to"`
+
+	// Direction of conversion. In inverse direction amount is given in target currency and
+	// converted amount is required amount of source currency.
+	Direction string `json:"direction,omitempty" xml:"direction,omitempty"`
+
+	// Rate used for conversion
+	Rate common.Decimal `json:"rate" xml:"rate"`
+
+	// Overridden is true if exchange rate was pinned by an override
+	Overridden bool `json:"overridden,omitempty" xml:"overridden,omitempty"`
+
+	// Number of providers and spread of their rates when exchange rate was aggregated
+	Aggregation *Aggregation `json:"aggregation,omitempty" xml:"aggregation,omitempty"`
+
+	// Date of exchange rate that was applied
+	Date string `json:"date,omitempty" xml:"date,omitempty"`
+
+	// Rounding mode applied to converted amount
+	Rounding common.RoundingMode `json:"rounding" xml:"rounding"`
+
+	// Provider that actually served exchange rate when served through fallback provider
+	Provider string `json:"provider,omitempty" xml:"provider,omitempty"`
+
+	// Providers that failed to serve exchange rate before the one that succeeded
+	Failures ProviderFailures `json:"failures,omitempty" xml:"failures,omitempty"`
+
+	// Converted amount in target currency
+	Converted common.Decimal `json:"converted" xml:"converted"`
+
+	// Pricing of converted amount when markups or fees were applied
+	Pricing *Price `json:"pricing,omitempty" xml:"pricing,omitempty"`
+}
+
+// Pair returns conversion result of a single target currency or false if it was not converted.
+func (c ConverterResponse) Pair(to string) (*PairResponse, bool) {
+	rate, rateExists := c.Rates[to]
+	converted, convertedExists := c.Converted[to]
+	if !rateExists || !convertedExists {
+		return nil, false
+	}
+
+	pair := &PairResponse{Amount: c.Amount, From: c.Currency, To: to, Direction: c.Direction,
+		Rate: rate, Date: c.Date, Rounding: c.Rounding, Provider: c.Provider, Failures: c.Failures,
+		Converted: converted}
+	for _, overridden := range c.Overridden {
+		pair.Overridden = pair.Overridden || overridden == to
+	}
+
+	if aggregation, exists := c.Aggregation[to]; exists {
+		pair.Aggregation = &aggregation
+	}
+
+	if price, exists := c.Pricing[to]; exists {
+		pair.Pricing = &price
+	}
+
+	return pair, true
 }
 
 // ConverterProvider is an abstract interface in order to allow providing multiple conversion
 // providers.
 type ConverterProvider interface {
-	Convert(float64, string) (*ConverterResponse, error)
+	Convert(context.Context, ConverterRequest) (*ConverterResponse, error)
+	// Rates returns exchange rates of base currency published on given date or the closest
+	// preceding business day. Latest rates are returned if date is zero. Only rates of given
+	// symbols are fetched, unless symbols are empty. Upstream requests are abandoned once given
+	// context is done.
+	Rates(ctx context.Context, base string, symbols []string, date time.Time) (*ExchangeRates,
+		error)
+	// Capabilities describes currencies served by provider and whether it serves historical rates
+	Capabilities() Capabilities
 	Name() string
 }
 
+// Returns rates of requested symbols only. Rate of base currency to itself is always 1. All
+// rates are returned if no symbols are given.
+func selectRates(rates Rates, base string, symbols []string) Rates {
+	if len(symbols) == 0 {
+		return rates
+	}
+
+	selected := make(Rates, len(symbols))
+	for _, symbol := range symbols {
+		if symbol == base {
+			selected[symbol] = common.NewDecimal(1, 0)
+		} else if rate, exists := rates[symbol]; exists {
+			selected[symbol] = rate
+		}
+	}
+
+	return selected
+}
+
+// RequireSymbols returns ErrRateNotFound if rates of any of given symbols are missing in given
+// rates of base currency published by provider with given name
+func RequireSymbols(rates Rates, base string, symbols []string, providerName string) error {
+	missing := make([]string, 0)
+	for _, symbol := range symbols {
+		if _, exists := rates[symbol]; !exists {
+			missing = append(missing, symbol)
+		}
+	}
+
+	if len(missing) > 0 {
+		return common.NewError(common.ErrRateNotFound,
+			"Rates %s/%s are not available in %s provider.", base, strings.Join(missing, ","),
+			providerName).WithParameter("to")
+	}
+
+	return nil
+}
+
+// Builds response for given request out of exchange rates and amounts converted with them
+func newConverterResponse(request ConverterRequest, rates *ExchangeRates,
+	converted ConvertedRates) *ConverterResponse {
+
+	direction := request.Direction
+	if direction == "" {
+		direction = DirectionForward
+	}
+
+	return &ConverterResponse{
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Direction:   direction,
+		Rounding:    request.Rounding,
+		Date:        rates.Date,
+		Derived:     rates.Pivot != "",
+		Pivot:       rates.Pivot,
+		Provider:    rates.Provider,
+		Failures:    rates.Failures,
+		Rates:       ConvertedRates(rates.Rates),
+		Overridden:  rates.Overridden,
+		Aggregation: rates.Aggregations,
+		Converted:   converted,
+		Cache:       rates.Cache,
+	}
+}
+
+// Multiplies every rate by given amount and rounds result to the minor unit of its currency
+func convertRates(rates map[string]common.Decimal, amount common.Decimal,
+	rounding common.RoundingMode) ConvertedRates {
+
+	converted := make(ConvertedRates, len(rates))
+	for cur, rate := range rates {
+		converted[cur] = common.RoundMoney(rate.Mul(amount), cur, rounding)
+	}
+
+	return converted
+}
+
+// Returns client sending given headers, or nil if there are none so that default client is used
+func newHTTPClient(headers map[string]string) *common.HTTPClient {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	client := common.NewHTTPClient(headers, common.DefaultRetryPolicy)
+	return &client
+}
+
+// Returns given client or the default one if it is nil
+func httpClient(client *common.HTTPClient) *common.HTTPClient {
+	if client == nil {
+		return &common.DefaultHTTPClient
+	}
+
+	return client
+}
+
 // GetProviders returns list of supported providers.
 func GetProviders() []ConverterProvider {
 	return []ConverterProvider{
 		NewFixerIOProvider(),
-		LocalProvider{},
+		NewECBProvider(),
+		NewLocalProvider(DefaultPivot),
 	}
 }
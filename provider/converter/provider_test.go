@@ -0,0 +1,170 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Returns converted rates in their exact string form, so that they can be easily compared
+func ratesToStrings(rates ConvertedRates) map[string]string {
+	result := make(map[string]string, len(rates))
+	for cur, rate := range rates {
+		result[cur] = rate.String()
+	}
+
+	return result
+}
+
+func TestConverterResponseMarshal(t *testing.T) {
+	cases := []struct {
+		response     ConverterResponse
+		expectedJSON string
+		expectedXML  string
+	}{
+		{
+			ConverterResponse{
+				Amount:    common.MustParseDecimal("0.10"),
+				Currency:  "PLN",
+				Rounding:  common.HalfEven,
+				Derived:   true,
+				Pivot:     "USD",
+				Converted: ConvertedRates{"EUR": common.MustParseDecimal("0.02")},
+			},
+			`{"amount":"0.10","currency":"PLN","rounding":"half-even","derived":true,"pivot":"USD",` +
+				`"converted":{"EUR":"0.02"}}`,
+			`<ConverterResponse><amount>0.10</amount><currency>PLN</currency>` +
+				`<rounding>half-even</rounding><derived>true</derived><pivot>USD</pivot>` +
+				`<converted><EUR>0.02</EUR></converted></ConverterResponse>`,
+		},
+	}
+
+	for _, c := range cases {
+		actualJSON, err := json.Marshal(c.response)
+		if err != nil || string(actualJSON) != c.expectedJSON {
+			t.Errorf("json.Marshal(%v) == \ngot: %s, %v \nexpected %s", c.response, actualJSON,
+				err, c.expectedJSON)
+		}
+
+		actualXML, err := xml.Marshal(c.response)
+		if err != nil || string(actualXML) != c.expectedXML {
+			t.Errorf("xml.Marshal(%v) == \ngot: %s, %v \nexpected %s", c.response, actualXML,
+				err, c.expectedXML)
+		}
+	}
+}
+
+func TestConvertedRatesFloat64(t *testing.T) {
+	cases := []struct {
+		rates    ConvertedRates
+		expected map[string]float64
+	}{
+		{
+			ConvertedRates{"USD": common.MustParseDecimal("123.40"), "JPY": common.NewDecimal(5, 0)},
+			map[string]float64{"USD": 123.4, "JPY": 5},
+		},
+	}
+
+	for _, c := range cases {
+		actual := c.rates.Float64()
+
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("ConvertedRates.Float64() == \ngot: %v, \nexpected %v", actual, c.expected)
+		}
+	}
+}
+
+func TestSelectRates(t *testing.T) {
+	rates := Rates{"USD": common.MustParseDecimal("0.25"), "EUR": common.MustParseDecimal("0.23")}
+	cases := []struct {
+		base     string
+		symbols  []string
+		expected map[string]string
+	}{
+		{"PLN", nil, map[string]string{"USD": "0.25", "EUR": "0.23"}},
+		{"PLN", []string{"EUR"}, map[string]string{"EUR": "0.23"}},
+		{"PLN", []string{"PLN", "USD"}, map[string]string{"PLN": "1", "USD": "0.25"}},
+		{"PLN", []string{"JPY"}, map[string]string{}},
+	}
+
+	for _, c := range cases {
+		actual := ratesToStrings(ConvertedRates(selectRates(rates, c.base, c.symbols)))
+
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("selectRates(%v, %s, %v) == \ngot: %v, \nexpected %v", rates, c.base,
+				c.symbols, actual, c.expected)
+		}
+	}
+}
+
+func TestConverterResponsePair(t *testing.T) {
+	response := ConverterResponse{
+		Amount:    common.NewDecimal(10, 0),
+		Currency:  "PLN",
+		Rounding:  common.HalfUp,
+		Rates:     ConvertedRates{"USD": common.MustParseDecimal("0.25292")},
+		Converted: ConvertedRates{"USD": common.MustParseDecimal("2.53")},
+	}
+	cases := []struct {
+		to       string
+		expected *PairResponse
+	}{
+		{"USD", &PairResponse{Amount: response.Amount, From: "PLN", To: "USD",
+			Rate: response.Rates["USD"], Rounding: common.HalfUp,
+			Converted: response.Converted["USD"]}},
+		{"EUR", nil},
+	}
+
+	for _, c := range cases {
+		actual, exists := response.Pair(c.to)
+
+		if !reflect.DeepEqual(actual, c.expected) || exists != (c.expected != nil) {
+			t.Errorf("ConverterResponse.Pair(%s) == \ngot: %v, \nexpected %v", c.to, actual,
+				c.expected)
+		}
+	}
+}
+
+func TestRequireSymbols(t *testing.T) {
+	rates := Rates{"USD": common.MustParseDecimal("0.25292"), "EUR": common.MustParseDecimal("0.23")}
+	cases := []struct {
+		symbols  []string
+		expected string
+	}{
+		{[]string{"USD", "EUR"}, ""},
+		{[]string{}, ""},
+		{[]string{"USD", "XAU", "JPY"}, "Rates PLN/XAU,JPY are not available in test provider."},
+	}
+
+	for _, c := range cases {
+		err := RequireSymbols(rates, "PLN", c.symbols, "test")
+
+		actual := ""
+		if err != nil {
+			actual = common.AsError(err).Message
+			if code := common.AsError(err).Code; code != common.ErrRateNotFound {
+				t.Errorf("RequireSymbols(%v) == \ngot code: %v, \nexpected %v", c.symbols, code,
+					common.ErrRateNotFound)
+			}
+		}
+		if actual != c.expected {
+			t.Errorf("RequireSymbols(%v) == \ngot: %v, \nexpected %v", c.symbols, actual,
+				c.expected)
+		}
+	}
+}
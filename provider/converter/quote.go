@@ -0,0 +1,70 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Directions of conversion
+const (
+	// DirectionForward - amount in request currency is converted to target currencies
+	DirectionForward = "forward"
+	// DirectionInverse - amount in every target currency is quoted in request currency
+	DirectionInverse = "inverse"
+)
+
+// Quote returns amounts in request currency that have to be converted to receive request amount
+// in every target currency. Quoted amounts are rounded up, so converting them with returned rates
+// never falls short of the target amount.
+func Quote(ctx context.Context, provider ConverterProvider,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := provider.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Direction = DirectionInverse
+	return convertRequest(request, rates), nil
+}
+
+// Returns response of request converted with given rates in direction of the request
+func convertRequest(request ConverterRequest, rates *ExchangeRates) *ConverterResponse {
+	if request.Direction == DirectionInverse {
+		request.Rounding = common.Ceiling
+		return newConverterResponse(request, rates,
+			quoteRates(rates.Rates, request.Currency, request.Amount))
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding))
+}
+
+// Returns amounts in base currency required to receive given amount in every target currency.
+// Target amount is first rounded up to the minor unit of its currency, so that it is reachable.
+func quoteRates(rates Rates, base string, amount common.Decimal) ConvertedRates {
+	quoted := make(ConvertedRates, len(rates))
+	for cur, rate := range rates {
+		if rate.Sign() <= 0 {
+			continue
+		}
+
+		target := common.RoundMoney(amount, cur, common.Ceiling)
+		quoted[cur] = target.Quo(rate, common.MinorUnits(base), common.Ceiling)
+	}
+
+	return quoted
+}
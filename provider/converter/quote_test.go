@@ -0,0 +1,59 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+func TestQuote(t *testing.T) {
+	provider := NewLocalProvider(DefaultPivot)
+	date := time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		amount   common.Decimal
+		target   string
+		expected string
+	}{
+		{common.MustParseDecimal("50"), "EUR", "216.40"},
+		{common.MustParseDecimal("50"), "USD", "197.70"},
+		// Yen has no minor unit, so 51 JPY have to be received
+		{common.MustParseDecimal("50.5"), "JPY", "1.92"},
+	}
+
+	for _, c := range cases {
+		request := ConverterRequest{Amount: c.amount, Currency: "PLN", Symbols: []string{c.target},
+			Date: date, Rounding: common.HalfUp}
+		response, err := Quote(context.Background(), provider, request)
+		if err != nil || response.Converted[c.target].String() != c.expected ||
+			response.Direction != DirectionInverse {
+			t.Errorf("Quote(%s %s) == \ngot: %v, %v \nexpected %s", c.amount, c.target, response,
+				err, c.expected)
+			continue
+		}
+
+		// Quoted amount has to be the smallest one that reaches the target amount
+		rate := response.Rates[c.target]
+		quoted := response.Converted[c.target]
+		target := common.RoundMoney(c.amount, c.target, common.Ceiling)
+		less := quoted.Sub(common.NewDecimal(1, common.MinorUnits("PLN")))
+		if common.RoundMoney(quoted.Mul(rate), c.target, common.HalfUp).Cmp(target) < 0 ||
+			less.Mul(rate).Cmp(target) >= 0 {
+			t.Errorf("Quote(%s %s) == %s is not the smallest amount reaching %s", c.amount,
+				c.target, quoted, target)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// DefaultSnapshotInterval - how often snapshot directory is checked for changes by default
+const DefaultSnapshotInterval = 10 * time.Second
+
+// Snapshots are exchange rates snapshots by their base currency
+type Snapshots map[string][]*LocalBaseRates
+
+// Adds snapshot replacing the one of the same base currency and date
+func (s Snapshots) add(snapshot *LocalBaseRates) {
+	for i, existing := range s[snapshot.Base] {
+		if existing.Date == snapshot.Date {
+			s[snapshot.Base][i] = snapshot
+			return
+		}
+	}
+
+	s[snapshot.Base] = append(s[snapshot.Base], snapshot)
+}
+
+// ParseSnapshot parses exchange rates snapshot in fixer-style {base, date, rates} JSON shape.
+// Base and target currencies have to be valid, date has to be given in DateFormat and rates have
+// to be positive.
+func ParseSnapshot(data []byte) (*LocalBaseRates, error) {
+	snapshot := new(LocalBaseRates)
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+
+	base, err := common.NormalizeCurrency(snapshot.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ParseDate(snapshot.Date); err != nil {
+		return nil, err
+	}
+
+	if len(snapshot.Rates) == 0 {
+		return nil, fmt.Errorf("Snapshot of %s rates for %s has no rates.", base, snapshot.Date)
+	}
+
+	rates := make(Rates, len(snapshot.Rates))
+	for currency, rate := range snapshot.Rates {
+		target, err := common.NormalizeCurrency(currency)
+		if err != nil {
+			return nil, err
+		}
+
+		if rate.Sign() <= 0 {
+			return nil, fmt.Errorf("Rate %s/%s has to be positive.", base, target)
+		}
+
+		rates[target] = rate
+	}
+
+	snapshot.Base = base
+	snapshot.Rates = rates
+	return snapshot, nil
+}
+
+// SnapshotDirectory serves exchange rates snapshots of JSON files (*.json, one snapshot per file)
+// in a directory together with built-in snapshots. Snapshots of files take precedence over built-in
+// ones of the same base currency and date. Directory is checked for changed, added and removed
+// files at most once per interval when snapshots are requested, invalid files are skipped.
+type SnapshotDirectory struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	checked   time.Time
+	signature string
+	snapshots Snapshots
+}
+
+// Snapshots returns current snapshots by their base currency, reloading the directory if it has
+// changed
+func (s *SnapshotDirectory) Snapshots() Snapshots {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.snapshots != nil && now.Sub(s.checked) < s.interval {
+		return s.snapshots
+	}
+
+	s.checked = now
+	files, signature, err := s.scan()
+	if err != nil {
+		log.Printf("Could not read snapshot directory %s: %s", s.path, err)
+	}
+
+	if s.snapshots == nil || signature != s.signature {
+		s.snapshots = s.load(files)
+		s.signature = signature
+	}
+
+	return s.snapshots
+}
+
+// Returns snapshot files of the directory and signature changing whenever any of them changes
+func (s *SnapshotDirectory) scan() ([]string, string, error) {
+	infos, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	files := make([]string, 0)
+	signature := ""
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(strings.ToLower(info.Name()), ".json") {
+			continue
+		}
+
+		files = append(files, filepath.Join(s.path, info.Name()))
+		signature += fmt.Sprintf("%s:%d:%d;", info.Name(), info.Size(),
+			info.ModTime().UnixNano())
+	}
+
+	sort.Strings(files)
+	return files, signature, nil
+}
+
+// Loads snapshots of given files on top of built-in snapshots
+func (s *SnapshotDirectory) load(files []string) Snapshots {
+	result := make(Snapshots)
+	for _, snapshots := range builtinSnapshots {
+		for _, snapshot := range snapshots {
+			result.add(snapshot)
+		}
+	}
+
+	loaded := 0
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Printf("Could not read snapshot %s: %s", file, err)
+			continue
+		}
+
+		snapshot, err := ParseSnapshot(data)
+		if err != nil {
+			log.Printf("Skipping invalid snapshot %s: %s", file, err)
+			continue
+		}
+
+		result.add(snapshot)
+		loaded++
+	}
+
+	log.Printf("Loaded %d snapshots from %s", loaded, s.path)
+	return result
+}
+
+// NewSnapshotDirectory returns snapshot directory of given path checked for changes at most once
+// per given interval
+func NewSnapshotDirectory(path string, interval time.Duration) *SnapshotDirectory {
+	return &SnapshotDirectory{path: path, interval: interval, now: time.Now}
+}
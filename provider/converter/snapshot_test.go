@@ -0,0 +1,124 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Writes given snapshot file to given directory
+func writeSnapshot(t *testing.T, dir, name, content string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalProviderSnapshotDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSnapshot(t, dir, "pln.json",
+		`{"base": "pln", "date": "2020-01-02", "rates": {"EUR": 0.25, "GBP": "0.2"}}`)
+	writeSnapshot(t, dir, "gbp.json", `{"base": "GBP", "date": "2020-01-02", "rates": {"PLN": 5}}`)
+	writeSnapshot(t, dir, "invalid.json", `{"base": "PLN", "date": "2020-01-03", "rates": {}}`)
+	writeSnapshot(t, dir, "notes.txt", `not a snapshot`)
+
+	provider := NewLocalProviderWithDirectory("", dir, 0)
+	latest := time.Time{}
+	historical := time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		base         string
+		symbol       string
+		date         time.Time
+		update       func()
+		expectedDate string
+		expectedRate string
+	}{
+		{"PLN", "EUR", latest, nil, "2020-01-02", "0.25"},
+		{"PLN", "EUR", historical, nil, "2016-10-31", "0.23106"},
+		{"GBP", "PLN", latest, nil, "2020-01-02", "5"},
+		{"PLN", "EUR", latest, func() {
+			writeSnapshot(t, dir, "pln-new.json",
+				`{"base": "PLN", "date": "2020-01-03", "rates": {"EUR": 0.26}}`)
+		}, "2020-01-03", "0.26"},
+		{"PLN", "EUR", latest, func() {
+			os.Remove(filepath.Join(dir, "pln.json"))
+			os.Remove(filepath.Join(dir, "pln-new.json"))
+		}, "2016-10-31", "0.23106"},
+	}
+
+	for _, c := range cases {
+		if c.update != nil {
+			c.update()
+		}
+
+		rates, err := provider.Rates(context.Background(), c.base, []string{c.symbol}, c.date)
+		if err != nil || rates.Date != c.expectedDate ||
+			rates.Rates[c.symbol].String() != c.expectedRate {
+			t.Errorf("LocalProvider.Rates(%s, %s, %s) == \ngot: %v, %v \nexpected %s %s", c.base,
+				c.symbol, c.date, rates, err, c.expectedDate, c.expectedRate)
+		}
+	}
+}
+
+func TestSnapshotDirectoryInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)
+	directory := NewSnapshotDirectory(dir, time.Minute)
+	directory.now = func() time.Time { return now }
+
+	if _, exists := directory.Snapshots()["GBP"]; exists {
+		t.Fatalf("Snapshots() == \ngot: GBP snapshot, \nexpected none")
+	}
+
+	writeSnapshot(t, dir, "gbp.json", `{"base": "GBP", "date": "2020-01-02", "rates": {"PLN": 5}}`)
+	if _, exists := directory.Snapshots()["GBP"]; exists {
+		t.Errorf("Snapshots() reloaded directory before interval passed")
+	}
+
+	now = now.Add(time.Minute)
+	if _, exists := directory.Snapshots()["GBP"]; !exists {
+		t.Errorf("Snapshots() did not reload directory after interval passed")
+	}
+}
+
+func TestParseSnapshotErrors(t *testing.T) {
+	cases := []string{
+		`{"base": "PLN", "date": "2020-01-02", "rates": {"EUR": 0.25}`,
+		`{"base": "XYZ", "date": "2020-01-02", "rates": {"EUR": 0.25}}`,
+		`{"base": "PLN", "date": "02.01.2020", "rates": {"EUR": 0.25}}`,
+		`{"base": "PLN", "date": "2020-01-02", "rates": {}}`,
+		`{"base": "PLN", "date": "2020-01-02", "rates": {"XYZ": 0.25}}`,
+		`{"base": "PLN", "date": "2020-01-02", "rates": {"EUR": 0}}`,
+	}
+
+	for _, c := range cases {
+		if snapshot, err := ParseSnapshot([]byte(c)); err == nil {
+			t.Errorf("ParseSnapshot(%s) == \ngot: %v, \nexpected error", c, snapshot)
+		}
+	}
+}
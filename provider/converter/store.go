@@ -0,0 +1,290 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// RateStore persists exchange rates tables fetched by providers.
+type RateStore interface {
+	// Save records exchange rates table fetched from given source
+	Save(source string, rates *ExchangeRates) error
+
+	// Find returns the newest exchange rates table of base currency published on or before given
+	// date by given source. Latest table is returned if date is zero and tables of all sources are
+	// considered if source is empty.
+	Find(source, base string, date time.Time) (*ExchangeRates, error)
+
+	// Bases returns sorted base currencies of all stored tables
+	Bases() []string
+}
+
+// StoredRates is a single exchange rates table record of a rate store
+type StoredRates struct {
+	// Source - name of the provider that published rates
+	Source string `json:"source"`
+	// Base - base currency
+	Base string `json:"base"`
+	// Date - date on which rates were published
+	Date string `json:"date"`
+	// Fetched - time at which rates were fetched from the source
+	Fetched time.Time `json:"fetched"`
+	// Rates - exchange rates
+	Rates Rates `json:"rates"`
+}
+
+// FileRateStore is a RateStore kept in a single file on disk. Every saved table is appended to
+// the file as a JSON line, whole file is loaded into memory on open. Tables saved more than once
+// for the same source, base and date and corrupted lines, e.g. left by a write interrupted by a
+// crash, are compacted on open. Records are indexed by base currency and ordered by date, so that
+// lookups do not scan the whole store.
+type FileRateStore struct {
+	path string
+
+	mu      *sync.RWMutex
+	records map[string]StoredRates
+	// Records of every base currency ordered by date and fetch time
+	bases map[string][]StoredRates
+}
+
+// Sorts stored records by date and then by fetch time
+type byDateFetched []StoredRates
+
+func (b byDateFetched) Len() int           { return len(b) }
+func (b byDateFetched) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byDateFetched) Less(i, j int) bool { return b[i].before(b[j]) }
+
+// Save appends exchange rates table to the store file. Table identical to the stored one of the
+// same source, base and date is not appended again.
+func (f FileRateStore) Save(source string, rates *ExchangeRates) error {
+	record := StoredRates{Source: source, Base: rates.Base, Date: rates.Date,
+		Fetched: time.Now().UTC(), Rates: rates.Rates}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if stored, exists := f.records[record.key()]; exists && equalRates(stored.Rates, record.Rates) {
+		return nil
+	}
+
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	f.put(record)
+	return file.Sync()
+}
+
+// Find returns the newest matching exchange rates table
+func (f FileRateStore) Find(source, base string, date time.Time) (*ExchangeRates, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	records := f.bases[base]
+	last := len(records)
+	if !date.IsZero() {
+		day := date.Format(DateFormat)
+		last = sort.Search(len(records), func(i int) bool { return records[i].Date > day })
+	}
+
+	for i := last - 1; i >= 0; i-- {
+		if source == "" || records[i].Source == source {
+			return &ExchangeRates{Base: records[i].Base, Date: records[i].Date,
+				Provider: records[i].Source, Rates: records[i].Rates}, nil
+		}
+	}
+
+	day := cacheKeyLatest
+	if !date.IsZero() {
+		day = date.Format(DateFormat)
+	}
+
+	return nil, common.NewError(common.ErrRateNotFound, "No %s rates stored for %s.", base, day)
+}
+
+// Bases returns sorted base currencies of all stored tables
+func (f FileRateStore) Bases() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	bases := make(map[string]bool, len(f.bases))
+	for base := range f.bases {
+		bases[base] = true
+	}
+
+	return sortedCurrencies(bases)
+}
+
+// Loads all records from the store file and compacts it if needed
+func (f FileRateStore) load() error {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	lines, corrupted := 0, 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lines++
+		var record StoredRates
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("Skipping corrupted line %d of rate store %s: %s", lines, f.path, err)
+			corrupted++
+			continue
+		}
+
+		f.records[record.key()] = record
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, record := range f.records {
+		f.bases[record.Base] = append(f.bases[record.Base], record)
+	}
+
+	for _, records := range f.bases {
+		sort.Sort(byDateFetched(records))
+	}
+
+	if lines > len(f.records) {
+		log.Printf("Compacting rate store %s: %d records, %d corrupted, %d unique", f.path,
+			lines, corrupted, len(f.records))
+		return f.compact()
+	}
+
+	return nil
+}
+
+// Keeps given record in place of the stored one of the same table. Has to be called with mutex
+// held.
+func (f FileRateStore) put(record StoredRates) {
+	records := f.bases[record.Base]
+	for i := range records {
+		if records[i].key() == record.key() {
+			records = append(records[:i], records[i+1:]...)
+			break
+		}
+	}
+
+	i := sort.Search(len(records), func(i int) bool { return record.before(records[i]) })
+	records = append(records, StoredRates{})
+	copy(records[i+1:], records[i:])
+	records[i] = record
+
+	f.records[record.key()] = record
+	f.bases[record.Base] = records
+}
+
+// Rewrites store file so that it contains only the most recent record of every table
+func (f FileRateStore) compact() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path))
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, record := range f.records {
+		line, err := json.Marshal(record)
+		if err == nil {
+			_, err = writer.Write(append(line, '\n'))
+		}
+
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Returns true if both tables contain the same rates
+func equalRates(a, b Rates) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for symbol, rate := range a {
+		if other, exists := b[symbol]; !exists || !rate.Equal(other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Identifies table by its source, base currency and date
+func (s StoredRates) key() string {
+	return fmt.Sprintf("%s/%s/%s", s.Source, s.Base, s.Date)
+}
+
+// Returns true if record was published before given one or fetched before it on the same date
+func (s StoredRates) before(other StoredRates) bool {
+	return s.Date < other.Date || (s.Date == other.Date && s.Fetched.Before(other.Fetched))
+}
+
+// OpenFileRateStore opens rate store kept in given file. File is created on first save if it
+// does not exist.
+func OpenFileRateStore(path string) (FileRateStore, error) {
+	store := FileRateStore{path: path, mu: new(sync.RWMutex),
+		records: make(map[string]StoredRates), bases: make(map[string][]StoredRates)}
+
+	if err := store.load(); err != nil {
+		return FileRateStore{}, err
+	}
+
+	return store, nil
+}
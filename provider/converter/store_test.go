@@ -0,0 +1,178 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+func TestFileRateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rates.db")
+	store, err := OpenFileRateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := new(int)
+	provider := NewRecordingProvider(dailyProvider{calls}, store)
+	for _, day := range []string{"2016-10-27", "2016-10-28", "2016-10-28"} {
+		date, _ := ParseDate(day)
+		_, err := provider.Rates(context.Background(), "PLN", []string{"USD"}, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reopened store has to contain all saved tables
+	store, err = OpenFileRateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		source   string
+		base     string
+		date     string
+		expected string
+	}{
+		{"", "PLN", "2016-10-28", "daily 2016-10-28"},
+		{"daily", "PLN", "2016-10-30", "daily 2016-10-28"},
+		{"daily", "PLN", "2016-10-27", "daily 2016-10-27"},
+		{"", "PLN", "", "daily 2016-10-28"},
+		{"daily", "PLN", "2016-10-26", ""},
+		{"fixerio", "PLN", "2016-10-28", ""},
+		{"", "EUR", "2016-10-28", ""},
+	}
+
+	for _, c := range cases {
+		var date time.Time
+		if c.date != "" {
+			date, _ = ParseDate(c.date)
+		}
+
+		rates, err := store.Find(c.source, c.base, date)
+		got := ""
+		if err == nil {
+			got = rates.Provider + " " + rates.Date
+		}
+
+		if got != c.expected {
+			t.Errorf("FileRateStore.Find(%s, %s, %s) == \ngot: %s, \nexpected %s", c.source,
+				c.base, c.date, got, c.expected)
+		}
+	}
+}
+
+func TestFileRateStoreLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Duplicated table and a line cut short by a crash
+	path := filepath.Join(dir, "rates.db")
+	line := `{"source":"daily","base":"PLN","date":"2016-10-28","rates":{"USD":"0.25"}}`
+	content := line + "\n" + line + "\n" + `{"source":"daily","base":"PLN","da`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenFileRateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		rates    string
+		expected int
+	}{
+		{"", 1},
+		{"0.25", 1},
+		{"0.26", 2},
+		{"0.26", 2},
+	}
+
+	for _, c := range cases {
+		if c.rates != "" {
+			err := store.Save("daily", &ExchangeRates{Base: "PLN", Date: "2016-10-28",
+				Rates: Rates{"USD": common.MustParseDecimal(c.rates)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lines := bytes.Count(content, []byte("\n")); lines != c.expected {
+			t.Errorf("FileRateStore.Save(%s) == \ngot: %d lines, \nexpected %d", c.rates, lines,
+				c.expected)
+		}
+	}
+
+	rates, err := store.Find("daily", "PLN", time.Time{})
+	if err != nil || rates.Rates["USD"].String() != "0.26" {
+		t.Errorf("FileRateStore.Find() == \ngot: %v (%v), \nexpected 0.26", rates, err)
+	}
+}
+
+func TestStoreProviderConvert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenFileRateStore(filepath.Join(dir, "rates.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date, _ := ParseDate("2016-10-31")
+	rates, _ := NewLocalProvider(DefaultPivot).Rates(context.Background(), "PLN", nil, date)
+	if err := store.Save(Local, rates); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := NewStoreProvider(store).Convert(context.Background(), ConverterRequest{
+		Amount: common.MustParseDecimal("100"), Currency: "PLN", Symbols: []string{"EUR"},
+		Date: date, Rounding: common.HalfUp})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"EUR": "23.11"}
+	got := ratesToStrings(response.Converted)
+	if !reflect.DeepEqual(got, expected) || response.Provider != Local {
+		t.Errorf("StoreProvider.Convert(100 PLN) == \ngot: %v %s, \nexpected %v %s", got,
+			response.Provider, expected, Local)
+	}
+}
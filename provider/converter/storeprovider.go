@@ -0,0 +1,158 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StoreProvider represents provider used to convert exchange rates based on rates saved in a rate
+// store. Implements ConverterProvider interface.
+type StoreProvider struct {
+	store RateStore
+}
+
+// Name returns name of this provider
+func (s StoreProvider) Name() string {
+	return Store
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (s StoreProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	log.Printf("Store provider - converting %s %s", request.Amount, request.Currency)
+
+	rates, err := s.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns stored exchange rates of base currency published on or before given date by any
+// source.
+func (s StoreProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	rates, err := s.store.Find("", base, date)
+	if err != nil {
+		return nil, err
+	}
+
+	rates.Rates = selectRates(rates.Rates, base, symbols)
+	return rates, nil
+}
+
+// Capabilities returns currencies of stored rates tables
+func (s StoreProvider) Capabilities() Capabilities {
+	bases, targets := make(map[string]bool), make(map[string]bool)
+	for _, base := range s.store.Bases() {
+		bases[base] = true
+		if rates, err := s.store.Find("", base, time.Time{}); err == nil {
+			for target := range rates.Rates {
+				targets[target] = true
+			}
+		}
+	}
+
+	return Capabilities{Bases: sortedCurrencies(bases), Targets: sortedCurrencies(targets),
+		Historical: true}
+}
+
+// NewStoreProvider returns provider serving rates saved in given store
+func NewStoreProvider(store RateStore) StoreProvider {
+	return StoreProvider{store: store}
+}
+
+// RecordingProvider wraps any provider and saves every base rates table it publishes into a rate
+// store. Implements ConverterProvider and TimeSeriesProvider interfaces.
+type RecordingProvider struct {
+	provider ConverterProvider
+	store    RateStore
+}
+
+// Name returns name of the wrapped provider
+func (r RecordingProvider) Name() string {
+	return r.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (r RecordingProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := r.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates fetches whole base rates table from wrapped provider and saves it, so that stored tables
+// are always complete. Derived rates are not saved.
+func (r RecordingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	rates, err := r.provider.Rates(ctx, base, nil, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.save(rates, symbols), nil
+}
+
+// TimeSeries fetches whole base rates tables published between start and end dates from wrapped
+// provider and saves them
+func (r RecordingProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	series, err := TimeSeries(ctx, r.provider, base, nil, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]*ExchangeRates, 0, len(series))
+	for _, rates := range series {
+		selected = append(selected, r.save(rates, symbols))
+	}
+
+	return selected, nil
+}
+
+// Saves whole base rates table unless it was derived and returns its rates of given symbols
+func (r RecordingProvider) save(rates *ExchangeRates, symbols []string) *ExchangeRates {
+	if rates.Pivot == "" {
+		if err := r.store.Save(r.provider.Name(), rates); err != nil {
+			log.Printf("Could not save %s rates of %s provider: %s", rates.Base, r.Name(), err)
+		}
+	}
+
+	return &ExchangeRates{Base: rates.Base, Date: rates.Date, Pivot: rates.Pivot,
+		Cache: rates.Cache, Rates: selectRates(rates.Rates, rates.Base, symbols)}
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (r RecordingProvider) Capabilities() Capabilities {
+	return r.provider.Capabilities()
+}
+
+// NewRecordingProvider returns provider saving rates published by given provider in given store
+func NewRecordingProvider(provider ConverterProvider, store RateStore) RecordingProvider {
+	return RecordingProvider{provider: provider, store: store}
+}
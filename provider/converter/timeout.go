@@ -0,0 +1,136 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// TimeoutError is returned when provider did not serve rates within its timeout
+type TimeoutError struct {
+	// Provider - name of the provider that timed out
+	Provider string
+	// Limit - how long provider was waited for
+	Limit time.Duration
+}
+
+// Error implements error interface
+func (t *TimeoutError) Error() string {
+	return fmt.Sprintf("Provider %s did not respond within %s.", t.Provider, t.Limit)
+}
+
+// Timeout is always true. Makes TimeoutError recognizable by IsTimeout.
+func (t *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Describe returns error returned to clients, which reports exceeded timeout of the provider
+func (t *TimeoutError) Describe() *common.Error {
+	return &common.Error{Code: common.ErrUpstreamTimeout, Message: t.Error()}
+}
+
+// IsTimeout returns true if given error was caused by an exceeded deadline, either of a provider
+// or of an upstream request
+func IsTimeout(err error) bool {
+	timeout, ok := err.(interface {
+		Timeout() bool
+	})
+
+	return ok && timeout.Timeout()
+}
+
+// TimeoutProvider wraps any provider and gives up waiting for its rates after a timeout.
+// Implements ConverterProvider and TimeSeriesProvider interfaces.
+type TimeoutProvider struct {
+	provider ConverterProvider
+	timeout  time.Duration
+}
+
+// Name returns name of the wrapped provider
+func (t TimeoutProvider) Name() string {
+	return t.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (t TimeoutProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := t.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of the wrapped provider or TimeoutError if they were not served
+// within timeout
+func (t TimeoutProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	var rates *ExchangeRates
+	err := t.call(ctx, func(timeoutCtx context.Context) (err error) {
+		rates, err = t.provider.Rates(timeoutCtx, base, symbols, date)
+		return err
+	})
+
+	return rates, err
+}
+
+// TimeSeries returns exchange rates of the wrapped provider published between start and end dates
+// or TimeoutError if they were not served within timeout
+func (t TimeoutProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	var series []*ExchangeRates
+	err := t.call(ctx, func(timeoutCtx context.Context) (err error) {
+		series, err = TimeSeries(timeoutCtx, t.provider, base, symbols, start, end)
+		return err
+	})
+
+	return series, err
+}
+
+// Calls given function with context limited by timeout. TimeoutError is returned if function
+// failed because of the timeout.
+func (t TimeoutProvider) call(ctx context.Context, fetch func(context.Context) error) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	err := fetch(timeoutCtx)
+	if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Provider: t.Name(), Limit: t.timeout}
+	}
+
+	return err
+}
+
+// Capabilities returns capabilities of the wrapped provider
+func (t TimeoutProvider) Capabilities() Capabilities {
+	return t.provider.Capabilities()
+}
+
+// NewTimeoutProvider returns provider waiting for rates of given provider up to given timeout.
+// Given provider is returned as it is if timeout is not positive.
+func NewTimeoutProvider(provider ConverterProvider, timeout time.Duration) ConverterProvider {
+	if timeout <= 0 {
+		return provider
+	}
+
+	return TimeoutProvider{provider: provider, timeout: timeout}
+}
@@ -0,0 +1,55 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeoutProviderRates(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		provider        ConverterProvider
+		ctx             context.Context
+		expectedError   bool
+		expectedTimeout bool
+	}{
+		{slowProvider{dailyProvider{new(int)}, time.Millisecond}, context.Background(), false,
+			false},
+		{slowProvider{dailyProvider{new(int)}, time.Second}, context.Background(), true, true},
+		{slowProvider{dailyProvider{new(int)}, time.Second}, canceled, true, false},
+		{failingProvider{dailyProvider{new(int)}, "down", errors.New("Down.")},
+			context.Background(), true, false},
+	}
+
+	for _, c := range cases {
+		provider := NewTimeoutProvider(c.provider, 20*time.Millisecond)
+		_, err := provider.Rates(c.ctx, "PLN", nil, time.Time{})
+		if (err != nil) != c.expectedError || IsTimeout(err) != c.expectedTimeout {
+			t.Errorf("TimeoutProvider.Rates(%s) == \ngot: %v, \nexpected error: %t (timeout: %t)",
+				c.provider.Name(), err, c.expectedError, c.expectedTimeout)
+		}
+	}
+}
+
+func TestNewTimeoutProvider(t *testing.T) {
+	provider := dailyProvider{new(int)}
+	if actual := NewTimeoutProvider(provider, 0); actual != provider {
+		t.Errorf("NewTimeoutProvider(daily, 0) == \ngot: %v, \nexpected %v", actual, provider)
+	}
+}
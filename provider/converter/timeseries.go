@@ -0,0 +1,164 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// MaxTimeSeriesDays is the longest date range that can be queried at once
+const MaxTimeSeriesDays = 366
+
+// TimeSeriesProvider is implemented by providers that are able to fetch exchange rates of a whole
+// date range at once. Decorators implement it as well and pass range queries to the wrapped
+// provider, which reports whether it serves them in its capabilities.
+type TimeSeriesProvider interface {
+	// TimeSeries returns exchange rates of base currency published between start and end dates
+	// (inclusive) ordered by date. Only rates of given symbols are fetched, unless symbols are
+	// empty.
+	TimeSeries(ctx context.Context, base string, symbols []string, start,
+		end time.Time) ([]*ExchangeRates, error)
+}
+
+// TimeSeriesEntry represents amount converted with exchange rates of a single day.
+type TimeSeriesEntry struct {
+	// Date of exchange rates
+	Date string `json:"date" xml:"date"`
+
+	// Exchange rates used for conversion
+	Rates ConvertedRates `json:"rates" xml:"rates"`
+
+	// Currencies whose exchange rates were pinned by overrides
+	Overridden []string `json:"overridden,omitempty" xml:"overridden,omitempty"`
+
+	// Converted rates based on amount and currency of time series
+	Converted ConvertedRates `json:"converted" xml:"converted"`
+}
+
+// TimeSeriesResponse is a structure returned for conversion over a date range.
+type TimeSeriesResponse struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"TimeSeriesResponse"`
+
+	// Amount of money which is a base for exchange rate calculation
+	Amount common.Decimal `json:"amount" xml:"amount"`
+
+	// Base currency of time series
+	Base string `json:"base" xml:"base"`
+
+	// Start date of time series
+	Start string `json:"start" xml:"start"`
+
+	// End date of time series
+	End string `json:"end" xml:"end"`
+
+	// Rounding mode applied to converted rates
+	Rounding common.RoundingMode `json:"rounding" xml:"rounding"`
+
+	// Entries - amounts converted with rates of every day in range
+	Entries []TimeSeriesEntry `json:"series" xml:"series>day"`
+}
+
+// ValidateDateRange checks that start date is not after end date and that range is not longer
+// than MaxTimeSeriesDays.
+func ValidateDateRange(start, end time.Time) error {
+	if end.Before(start) {
+		return common.NewError(common.ErrInvalidParameter, "Start date %s is after end date %s.",
+			start.Format(DateFormat), end.Format(DateFormat))
+	}
+
+	if end.Sub(start) >= MaxTimeSeriesDays*24*time.Hour {
+		return common.NewError(common.ErrInvalidParameter,
+			"Date range can not be longer than %d days.", MaxTimeSeriesDays)
+	}
+
+	return nil
+}
+
+// TimeSeries returns exchange rates of every business day between start and end dates. Range
+// query of the provider is used if it implements TimeSeriesProvider and reports TimeSeries
+// capability, otherwise rates of every day are looked up separately. Days without published rates
+// (weekends, holidays, days for which provider has no rates) are skipped.
+func TimeSeries(ctx context.Context, provider ConverterProvider, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	if err := ValidateDateRange(start, end); err != nil {
+		return nil, err
+	}
+
+	timeSeriesProvider, ok := provider.(TimeSeriesProvider)
+	if ok && provider.Capabilities().TimeSeries {
+		return timeSeriesProvider.TimeSeries(ctx, base, symbols, start, end)
+	}
+
+	series := make([]*ExchangeRates, 0)
+	seen := make(map[string]bool)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rates, err := provider.Rates(ctx, base, symbols, day)
+		if err != nil {
+			if common.AsError(err).Code == common.ErrRateNotFound {
+				continue
+			}
+
+			return nil, err
+		}
+
+		// Rates of the previous business day are returned for days without published rates
+		if seen[rates.Date] || rates.Date < start.Format(DateFormat) {
+			continue
+		}
+
+		seen[rates.Date] = true
+		series = append(series, rates)
+	}
+
+	return series, nil
+}
+
+// ConvertTimeSeries converts amount of request with exchange rates of every business day between
+// start and end dates.
+func ConvertTimeSeries(ctx context.Context, provider ConverterProvider, request ConverterRequest,
+	start, end time.Time) (*TimeSeriesResponse, error) {
+
+	series, err := TimeSeries(ctx, provider, request.Currency, request.Symbols, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TimeSeriesEntry, 0, len(series))
+	for _, rates := range series {
+		entries = append(entries, TimeSeriesEntry{
+			Date:       rates.Date,
+			Rates:      ConvertedRates(rates.Rates),
+			Overridden: rates.Overridden,
+			Converted:  convertRates(rates.Rates, request.Amount, request.Rounding),
+		})
+	}
+
+	return &TimeSeriesResponse{
+		Amount:   request.Amount,
+		Base:     request.Currency,
+		Start:    start.Format(DateFormat),
+		End:      end.Format(DateFormat),
+		Rounding: request.Rounding,
+		Entries:  entries,
+	}, nil
+}
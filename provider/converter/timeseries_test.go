@@ -0,0 +1,187 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// Provider publishing rate equal to the day of month on business days only
+type dailyProvider struct {
+	calls *int
+}
+
+func (d dailyProvider) Name() string {
+	return "daily"
+}
+
+func (d dailyProvider) Capabilities() Capabilities {
+	return Capabilities{Bases: []string{"EUR", "PLN"}, Targets: []string{"USD"}, Historical: true}
+}
+
+func (d dailyProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	return nil, nil
+}
+
+func (d dailyProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	*d.calls++
+	date = lastBusinessDay(date)
+	return &ExchangeRates{Base: base, Date: date.Format(DateFormat),
+		Rates: Rates{"USD": common.NewDecimal(int64(date.Day()), 0)}}, nil
+}
+
+// Provider supporting range queries
+type rangeProvider struct {
+	dailyProvider
+}
+
+func (r rangeProvider) Capabilities() Capabilities {
+	capabilities := r.dailyProvider.Capabilities()
+	capabilities.TimeSeries = true
+	return capabilities
+}
+
+func (r rangeProvider) TimeSeries(ctx context.Context, base string, symbols []string,
+	start, end time.Time) ([]*ExchangeRates, error) {
+
+	return []*ExchangeRates{{Base: base, Date: start.Format(DateFormat)}}, nil
+}
+
+// Provider without rates published before given day
+type sparseProvider struct {
+	dailyProvider
+	first string
+}
+
+func (s sparseProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	if date.Format(DateFormat) < s.first {
+		*s.calls++
+		return nil, common.NewError(common.ErrRateNotFound, "No rates for %s.", date)
+	}
+
+	return s.dailyProvider.Rates(ctx, base, symbols, date)
+}
+
+func TestTimeSeries(t *testing.T) {
+	failing := new(bool)
+	*failing = true
+
+	cases := []struct {
+		provider      ConverterProvider
+		start, end    string
+		expected      []string
+		expectedCalls int
+		expectedError bool
+	}{
+		{dailyProvider{new(int)}, "2016-10-28", "2016-11-01",
+			[]string{"2016-10-28", "2016-10-31", "2016-11-01"}, 5, false},
+		{dailyProvider{new(int)}, "2016-10-29", "2016-10-31", []string{"2016-10-31"}, 3, false},
+		{rangeProvider{dailyProvider{new(int)}}, "2016-10-28", "2016-11-01",
+			[]string{"2016-10-28"}, 0, false},
+		{sparseProvider{dailyProvider{new(int)}, "2016-10-31"}, "2016-10-28", "2016-11-01",
+			[]string{"2016-10-31", "2016-11-01"}, 5, false},
+		{unavailableProvider{dailyProvider{new(int)}, failing, &common.HTTPError{StatusCode: 503}},
+			"2016-10-28", "2016-11-01", nil, 1, true},
+		{dailyProvider{new(int)}, "2016-11-01", "2016-10-28", nil, 0, true},
+		{dailyProvider{new(int)}, "2016-01-01", "2017-01-01", nil, 0, true},
+	}
+
+	for _, c := range cases {
+		start, _ := ParseDate(c.start)
+		end, _ := ParseDate(c.end)
+		series, err := TimeSeries(context.Background(), c.provider, "PLN", nil, start, end)
+
+		if (err != nil) != c.expectedError {
+			t.Errorf("TimeSeries(%s, %s) returned error: %v", c.start, c.end, err)
+			continue
+		}
+
+		var actual []string
+		for _, rates := range series {
+			actual = append(actual, rates.Date)
+		}
+
+		var calls int
+		switch p := c.provider.(type) {
+		case dailyProvider:
+			calls = *p.calls
+		case rangeProvider:
+			calls = *p.calls
+		case sparseProvider:
+			calls = *p.calls
+		case unavailableProvider:
+			calls = *p.calls
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) || calls != c.expectedCalls {
+			t.Errorf("TimeSeries(%s, %s) == \ngot: %v (%d calls), \nexpected %v (%d calls)",
+				c.start, c.end, actual, calls, c.expected, c.expectedCalls)
+		}
+	}
+}
+
+func TestConvertTimeSeries(t *testing.T) {
+	start, _ := ParseDate("2016-10-28")
+	end, _ := ParseDate("2016-10-31")
+	request := ConverterRequest{Amount: common.MustParseDecimal("1.5"), Currency: "PLN",
+		Rounding: common.HalfUp}
+
+	response, err := ConvertTimeSeries(context.Background(), dailyProvider{new(int)}, request,
+		start, end)
+	if err != nil {
+		t.Fatalf("ConvertTimeSeries(%v) returned error: %v", request, err)
+	}
+
+	expected := map[string]string{"2016-10-28": "42.00", "2016-10-31": "46.50"}
+	actual := make(map[string]string)
+	for _, entry := range response.Entries {
+		actual[entry.Date] = entry.Converted["USD"].String()
+	}
+
+	if !reflect.DeepEqual(actual, expected) || response.Start != "2016-10-28" ||
+		response.End != "2016-10-31" || response.Base != "PLN" {
+		t.Errorf("ConvertTimeSeries(%v) == \ngot: %v, \nexpected %v", request, actual, expected)
+	}
+}
+
+func TestTimeSeriesThroughDecorators(t *testing.T) {
+	store, dir := openOverrideStore(t)
+	defer os.RemoveAll(dir)
+
+	calls := new(int)
+	var provider ConverterProvider = rangeProvider{dailyProvider{calls}}
+	provider = NewTimeoutProvider(NewNamedProvider(provider, "range"), time.Minute)
+	provider = NewCircuitBreakerProvider(provider, DefaultBreakerSettings)
+	provider = NewPrefetchingProvider(NewCachingProvider(provider, time.Minute), nil, Schedule{})
+	provider = NewMonitoringProvider(NewOverridingProvider(provider, store))
+
+	start, _ := ParseDate("2016-10-28")
+	end, _ := ParseDate("2016-11-01")
+	series, err := TimeSeries(context.Background(), provider, "PLN", nil, start, end)
+	if err != nil || len(series) != 1 || *calls != 0 {
+		t.Errorf("TimeSeries() through decorators == \ngot: %v, %v (%d calls), \nexpected "+
+			"range query", series, err, *calls)
+	}
+}
@@ -0,0 +1,123 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/floreks/go-currency/common"
+)
+
+// DefaultPivot is a currency through which cross rates are derived by default
+const DefaultPivot = "EUR"
+
+// Number of decimal places of derived cross rates
+const crossRateScale = 12
+
+// Triangulate derives exchange rates of base currency from exchange rates published for pivot
+// currency, i.e. SEK/NOK = EUR/NOK / EUR/SEK. Only rates of given symbols are derived, unless
+// symbols are empty.
+func Triangulate(pivotRates *ExchangeRates, base string, symbols []string) (*ExchangeRates, error) {
+	pivot := pivotRates.Base
+	if base == pivot {
+		return &ExchangeRates{Base: base, Date: pivotRates.Date, Cache: pivotRates.Cache,
+			Rates: selectRates(pivotRates.Rates, base, symbols)}, nil
+	}
+
+	pivotToBase, exists := pivotRates.Rates[base]
+	if !exists || pivotToBase.IsZero() {
+		log.Printf("Rate %s/%s is not available. Can not triangulate.", pivot, base)
+		return nil, common.NewError(common.ErrUnsupportedCurrency,
+			"Rate %s/%s is not available. Can not triangulate.", pivot, base)
+	}
+
+	// Rate of pivot currency itself is always 1
+	rates := make(Rates, len(pivotRates.Rates)+1)
+	rates[pivot] = common.NewDecimal(1, 0)
+	for cur, rate := range pivotRates.Rates {
+		rates[cur] = rate
+	}
+
+	if len(symbols) == 0 {
+		for cur := range rates {
+			if cur != base {
+				symbols = append(symbols, cur)
+			}
+		}
+	}
+
+	derived := make(Rates, len(symbols))
+	for _, symbol := range symbols {
+		if symbol == base {
+			derived[symbol] = common.NewDecimal(1, 0)
+		} else if rate, exists := rates[symbol]; exists {
+			derived[symbol] = rate.Quo(pivotToBase, crossRateScale, common.HalfEven)
+		}
+	}
+
+	return &ExchangeRates{Base: base, Date: pivotRates.Date, Pivot: pivot, Cache: pivotRates.Cache,
+		Rates: derived}, nil
+}
+
+// TriangulatingProvider wraps provider that publishes rates of a single base currency only and
+// derives rates of any other base currency through it. Implements ConverterProvider interface.
+type TriangulatingProvider struct {
+	provider ConverterProvider
+	pivot    string
+}
+
+// Name returns name of the wrapped provider
+func (t TriangulatingProvider) Name() string {
+	return t.provider.Name()
+}
+
+// Convert - takes the amount in one currency and converts it to other currencies
+func (t TriangulatingProvider) Convert(ctx context.Context,
+	request ConverterRequest) (*ConverterResponse, error) {
+
+	rates, err := t.Rates(ctx, request.Currency, request.Symbols, request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverterResponse(request, rates,
+		convertRates(rates.Rates, request.Amount, request.Rounding)), nil
+}
+
+// Rates returns exchange rates of base currency derived from rates of pivot currency
+func (t TriangulatingProvider) Rates(ctx context.Context, base string, symbols []string,
+	date time.Time) (*ExchangeRates, error) {
+
+	pivotRates, err := t.provider.Rates(ctx, t.pivot, nil, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return Triangulate(pivotRates, base, symbols)
+}
+
+// Capabilities returns currencies of the wrapped provider. Any of them can be used as a base, as
+// long as it is published in pivot rates.
+func (t TriangulatingProvider) Capabilities() Capabilities {
+	capabilities := t.provider.Capabilities()
+	return mergeCapabilities(capabilities, Capabilities{Bases: capabilities.Targets,
+		Historical: capabilities.Historical})
+}
+
+// NewTriangulatingProvider returns provider deriving rates of any base currency from rates of
+// given pivot currency published by given provider.
+func NewTriangulatingProvider(provider ConverterProvider, pivot string) TriangulatingProvider {
+	return TriangulatingProvider{provider: provider, pivot: pivot}
+}
@@ -0,0 +1,96 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/floreks/go-currency/common"
+)
+
+func TestTriangulate(t *testing.T) {
+	pivotRates := &ExchangeRates{
+		Base: "EUR",
+		Date: "2016-10-31",
+		Rates: Rates{
+			"SEK": common.MustParseDecimal("9.865"),
+			"NOK": common.MustParseDecimal("9.0345"),
+			"PLN": common.MustParseDecimal("4.3278"),
+		},
+	}
+	cases := []struct {
+		base          string
+		symbols       []string
+		expected      map[string]string
+		expectedPivot string
+		expectedError bool
+	}{
+		{"SEK", []string{"NOK"}, map[string]string{"NOK": "0.915813482007"}, "EUR", false},
+		{"SEK", []string{"EUR", "SEK"}, map[string]string{"EUR": "0.101368474404", "SEK": "1"},
+			"EUR", false},
+		{"PLN", nil, map[string]string{"EUR": "0.231064282083", "SEK": "2.279449142752",
+			"NOK": "2.087550256481"}, "EUR", false},
+		{"EUR", []string{"PLN"}, map[string]string{"PLN": "4.3278"}, "", false},
+		{"JPY", []string{"PLN"}, nil, "", true},
+	}
+
+	for _, c := range cases {
+		rates, err := Triangulate(pivotRates, c.base, c.symbols)
+		if (err != nil) != c.expectedError {
+			t.Errorf("Triangulate(%s, %v) returned error: %v", c.base, c.symbols, err)
+			continue
+		}
+
+		if rates == nil {
+			continue
+		}
+
+		actual := ratesToStrings(ConvertedRates(rates.Rates))
+		if !reflect.DeepEqual(actual, c.expected) || rates.Pivot != c.expectedPivot ||
+			rates.Base != c.base || rates.Date != pivotRates.Date {
+			t.Errorf("Triangulate(%s, %v) == \ngot: %v %s, \nexpected %v %s", c.base, c.symbols,
+				actual, rates.Pivot, c.expected, c.expectedPivot)
+		}
+	}
+}
+
+func TestTriangulatingProvider(t *testing.T) {
+	provider := NewTriangulatingProvider(LocalProvider{}, currencyUSD)
+	cases := []struct {
+		request  ConverterRequest
+		expected map[string]string
+	}{
+		{
+			ConverterRequest{Amount: common.NewDecimal(100, 0), Currency: "SEK",
+				Symbols: []string{"NOK", "USD"}, Rounding: common.HalfUp},
+			map[string]string{"NOK": "91.58", "USD": "11.10"},
+		},
+	}
+
+	for _, c := range cases {
+		response, err := provider.Convert(context.Background(), c.request)
+		if err != nil {
+			t.Errorf("TriangulatingProvider.Convert(%v) returned error: %v", c.request, err)
+			continue
+		}
+
+		actual := ratesToStrings(response.Converted)
+		if !reflect.DeepEqual(actual, c.expected) || !response.Derived ||
+			response.Pivot != currencyUSD || provider.Name() != Local {
+			t.Errorf("TriangulatingProvider.Convert(%v) == \ngot: %v, \nexpected %v", c.request,
+				actual, c.expected)
+		}
+	}
+}
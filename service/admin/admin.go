@@ -0,0 +1,197 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/floreks/go-currency/common"
+	"github.com/floreks/go-currency/provider/converter"
+)
+
+// OverrideList is a structure returned by admin service. Needed for correct xml response.
+type OverrideList struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"overrides"`
+
+	// Overrides applied to rates served by providers
+	Overrides []converter.Override `json:"overrides" xml:"override"`
+}
+
+// AdminService manages overrides pinning exchange rates regardless of rates served by providers.
+// Every request has to carry admin token as a bearer token in Authorization header.
+type AdminService struct {
+	overrides converter.OverrideStore
+
+	// Token required by every request
+	token string
+}
+
+// Handler registers endpoints and returns handler for admin service
+func (a AdminService) Handler() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.
+		Path("/admin/overrides").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(a.authenticate)
+
+	ws.Route(ws.GET("/").To(a.list).
+		Doc("Lists all overrides").
+		Param(ws.QueryParameter("tenant", "Lists only overrides applying to given tenant").
+			DataType("string")).
+		Writes(OverrideList{}))
+
+	ws.Route(ws.POST("/").To(a.create).
+		Doc("Creates override").
+		Reads(converter.Override{}).
+		Writes(converter.Override{}))
+
+	ws.Route(ws.GET("/{id}").To(a.get).
+		Doc("Returns override with given ID").
+		Param(ws.PathParameter("id", "ID of the override").DataType("string")).
+		Writes(converter.Override{}))
+
+	ws.Route(ws.PUT("/{id}").To(a.update).
+		Doc("Replaces override with given ID").
+		Param(ws.PathParameter("id", "ID of the override").DataType("string")).
+		Reads(converter.Override{}).
+		Writes(converter.Override{}))
+
+	ws.Route(ws.DELETE("/{id}").To(a.delete).
+		Doc("Removes override with given ID").
+		Param(ws.PathParameter("id", "ID of the override").DataType("string")))
+
+	return ws
+}
+
+// Rejects requests that do not carry admin token. Service without a token rejects all requests.
+func (a AdminService) authenticate(request *restful.Request, response *restful.Response,
+	chain *restful.FilterChain) {
+
+	token := strings.TrimPrefix(request.HeaderParameter("Authorization"), "Bearer ")
+	if len(a.token) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		log.Printf("Unauthorized admin request %s %s rejected", request.Request.Method,
+			request.Request.URL.Path)
+		response.AddHeader("WWW-Authenticate", "Bearer")
+		writeError(response, common.NewError(common.ErrUnauthorized,
+			"Admin token is missing or invalid."))
+		return
+	}
+
+	chain.ProcessFilter(request, response)
+}
+
+func (a AdminService) list(request *restful.Request, response *restful.Response) {
+	tenant := request.QueryParameter("tenant")
+	list := OverrideList{Overrides: make([]converter.Override, 0)}
+	for _, override := range a.overrides.List() {
+		if len(tenant) == 0 || override.Tenant == "" || override.Tenant == tenant {
+			list.Overrides = append(list.Overrides, override)
+		}
+	}
+
+	response.WriteHeaderAndEntity(http.StatusOK, list)
+}
+
+func (a AdminService) create(request *restful.Request, response *restful.Response) {
+	override, err := readOverride(request)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	override.ID = ""
+	if override, err = a.overrides.Save(override); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	log.Printf("Created override %s of %s/%s: %s", override.ID, override.From, override.To,
+		override.Rate)
+	response.WriteHeaderAndEntity(http.StatusCreated, override)
+}
+
+func (a AdminService) get(request *restful.Request, response *restful.Response) {
+	override, err := a.overrides.Get(request.PathParameter("id"))
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	response.WriteHeaderAndEntity(http.StatusOK, override)
+}
+
+func (a AdminService) update(request *restful.Request, response *restful.Response) {
+	id := request.PathParameter("id")
+	if _, err := a.overrides.Get(id); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	override, err := readOverride(request)
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+
+	override.ID = id
+	if override, err = a.overrides.Save(override); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	log.Printf("Updated override %s of %s/%s: %s", override.ID, override.From, override.To,
+		override.Rate)
+	response.WriteHeaderAndEntity(http.StatusOK, override)
+}
+
+func (a AdminService) delete(request *restful.Request, response *restful.Response) {
+	id := request.PathParameter("id")
+	if err := a.overrides.Delete(id); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	log.Printf("Deleted override %s", id)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// Reads override from request body
+func readOverride(request *restful.Request) (converter.Override, error) {
+	override := converter.Override{}
+	if err := request.ReadEntity(&override); err != nil {
+		log.Printf("Override is invalid: %s", err)
+		return override, common.NewError(common.ErrInvalidParameter, "Override is invalid: %s",
+			err)
+	}
+
+	return override, nil
+}
+
+// Writes error as a response body with status matching its code, see common.AsError
+func writeError(response *restful.Response, err error) {
+	clientErr := common.AsError(err)
+	response.WriteHeaderAndEntity(clientErr.Status(), clientErr)
+}
+
+// NewAdminService returns initialized AdminService object managing overrides of given store.
+// Requests have to carry given token.
+func NewAdminService(overrides converter.OverrideStore, token string) AdminService {
+	return AdminService{overrides: overrides, token: token}
+}
@@ -0,0 +1,186 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/floreks/go-currency/common"
+	"github.com/floreks/go-currency/provider/converter"
+)
+
+// MaxBatchItems is the largest number of conversions accepted in a single batch
+const MaxBatchItems = 1000
+
+// BatchAmount is an amount of a batch item given either as a JSON number or a string. It is kept
+// unparsed, so that invalid amounts are reported for the item only.
+type BatchAmount string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *BatchAmount) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = BatchAmount(value)
+		return nil
+	}
+
+	*b = BatchAmount(data)
+	return nil
+}
+
+// BatchItem is a single conversion of a batch
+type BatchItem struct {
+	// Amount of money that should be converted
+	Amount BatchAmount `json:"amount" xml:"amount"`
+
+	// From is a currency of the amount
+	From string `json:"from" xml:"from"`
+
+	// To limits conversion to given currencies. All known currencies are used if empty.
+	To []string `json:"to,omitempty" xml:"to,omitempty"`
+
+	// Provider used for conversion. Default provider is used if empty or unknown.
+	Provider string `json:"provider,omitempty" xml:"provider,omitempty"`
+
+	// Date of exchange rates (YYYY-MM-DD). Latest rates are used if empty.
+	Date string `json:"date,omitempty" xml:"date,omitempty"`
+
+	// Rounding mode applied to converted amounts
+	Rounding string `json:"rounding,omitempty" xml:"rounding,omitempty"`
+
+	// Direction of conversion. In inverse direction amount is given in target currencies.
+	Direction string `json:"direction,omitempty" xml:"direction,omitempty"`
+
+	// Fees - fee profile charged for conversion
+	Fees string `json:"fees,omitempty" xml:"fees,omitempty"`
+}
+
+// BatchRequest is a list of conversions
+type BatchRequest struct {
+	// XMLName needed for correct xml request
+	XMLName xml.Name `json:"-" xml:"batch"`
+
+	// Items - conversions that should be done
+	Items []BatchItem `json:"items" xml:"item"`
+}
+
+// BatchResult is an outcome of a single conversion of a batch. Either result or error is set.
+type BatchResult struct {
+	// Result of the conversion
+	Result *converter.ConverterResponse `json:"result,omitempty" xml:"ConverterResponse,omitempty"`
+
+	// Error that prevented the conversion
+	Error *common.Error `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// BatchResponse lists outcomes of batch conversions in order of requested items
+type BatchResponse struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"batch"`
+
+	// Results of conversions
+	Results []BatchResult `json:"results" xml:"result"`
+}
+
+func (c ConverterService) convertBatch(request *restful.Request, response *restful.Response) {
+	batchRequest := new(BatchRequest)
+	if err := request.ReadEntity(batchRequest); err != nil {
+		log.Printf("Batch request is invalid: %s", err)
+		writeError(request, response, common.NewError(common.ErrInvalidParameter,
+			"Batch request is invalid: %s", err))
+		return
+	}
+
+	if len(batchRequest.Items) == 0 || len(batchRequest.Items) > MaxBatchItems {
+		log.Printf("Batch request has %d items.", len(batchRequest.Items))
+		writeError(request, response, common.NewError(common.ErrInvalidParameter,
+			"Batch request has to contain from 1 to %d items.", MaxBatchItems).
+			WithParameter("items"))
+		return
+	}
+
+	results := c.doConvertBatch(request, batchRequest.Items)
+	if err := request.Request.Context().Err(); err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	response.WriteHeaderAndEntity(http.StatusOK, BatchResponse{Results: results})
+}
+
+// Converts valid items grouped by provider, so that every provider can share exchange rates
+// lookups between items. Invalid items are reported without asking any provider.
+func (c ConverterService) doConvertBatch(request *restful.Request,
+	items []BatchItem) []BatchResult {
+
+	results := make([]BatchResult, len(items))
+	fees := make([]*converter.FeeProfile, len(items))
+	providers := make([]converter.ConverterProvider, 0)
+	indexes := make(map[string][]int)
+	requests := make(map[string][]converter.ConverterRequest)
+	for i, item := range items {
+		query, err := c.parseParams(queryParams{
+			amount:       string(item.Amount),
+			currencyName: "from",
+			currency:     item.From,
+			symbolsName:  "to",
+			symbols:      item.To,
+			date:         item.Date,
+			rounding:     item.Rounding,
+			direction:    item.Direction,
+			fees:         item.Fees,
+			provider:     item.Provider,
+		})
+		if err != nil {
+			results[i].Error = common.AsError(err)
+			continue
+		}
+
+		fees[i] = query.Fees
+		name := query.Provider.Name()
+		if _, exists := indexes[name]; !exists {
+			providers = append(providers, query.Provider)
+		}
+
+		indexes[name] = append(indexes[name], i)
+		requests[name] = append(requests[name], converter.ConverterRequest{
+			Amount:    query.Amount,
+			Currency:  query.Currency,
+			Symbols:   query.Symbols,
+			Date:      query.Date,
+			Rounding:  query.Rounding,
+			Direction: query.Direction,
+		})
+	}
+
+	for _, provider := range providers {
+		name := provider.Name()
+		responses, errs := converter.ConvertBatch(requestContext(request), provider,
+			requests[name])
+		for j, i := range indexes[name] {
+			if errs[j] != nil {
+				results[i].Error = common.AsError(errs[j])
+				continue
+			}
+
+			c.pricer.Apply(responses[j], fees[i])
+			results[i].Result = responses[j]
+		}
+	}
+
+	return results
+}
@@ -13,33 +13,67 @@
 package converter
 
 import (
-	"errors"
+	"context"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
-	"fmt"
 	"github.com/emicklei/go-restful"
+	"github.com/floreks/go-currency/common"
 	"github.com/floreks/go-currency/provider/converter"
 )
 
+// Header carrying API key of the tenant on whose behalf request is made
+const tenantKeyHeader = "X-Api-Key"
+
+// Request attribute holding tenant authenticated by its API key
+const tenantAttribute = "tenant"
+
 // ConverterQuery represents request query parameters(required and optional) used for conversion.
 type ConverterQuery struct {
 	// Amount represents amount of money that should be converted to another currency.
-	Amount float64
+	Amount common.Decimal
 
 	// Currency represents target currency to which conversion should be applied.
 	Currency string
 
+	// Symbols is an optional parameter that limits conversion to given currencies.
+	Symbols []string
+
+	// Date is an optional parameter that represents date of exchange rates used for conversion.
+	Date time.Time
+
+	// Rounding is an optional parameter that represents rounding mode applied to converted amounts.
+	Rounding common.RoundingMode
+
+	// Direction is an optional parameter. In inverse direction amount is given in target currencies.
+	Direction string
+
+	// Fees is an optional fee profile charged for conversion
+	Fees *converter.FeeProfile
+
 	// Provider is a optional parameter that represents provider that should be used for conversion.
 	Provider converter.ConverterProvider
 }
 
 // ConverterService converts given amount of money in given currency to currencies supported by
-// selected provider. By default FixerIO provider is used for conversion.
+// selected provider. Configured default provider is used when request does not select one.
 type ConverterService struct {
 	providers []converter.ConverterProvider
+
+	// Name of the provider used when request does not select one
+	defaultProvider string
+
+	// Rounding mode used when request does not specify one
+	rounding common.RoundingMode
+
+	// Applies markups and fees to converted amounts
+	pricer converter.Pricer
+
+	// Tenant names by their API keys
+	tenants map[string]string
 }
 
 func (c ConverterService) getProvider(providerName string) converter.ConverterProvider {
@@ -53,7 +87,7 @@ func (c ConverterService) getProvider(providerName string) converter.ConverterPr
 }
 
 func (c ConverterService) getDefaultProvider() converter.ConverterProvider {
-	return c.getProvider(converter.FixerIO)
+	return c.getProvider(c.defaultProvider)
 }
 
 // Handler registers endpoints and returns handler for converter service
@@ -62,61 +96,420 @@ func (c ConverterService) Handler() *restful.WebService {
 	ws.
 		Path("/convert").
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
-		Produces(restful.MIME_JSON, restful.MIME_XML)
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(c.authenticateTenant)
 
 	ws.Route(ws.GET("/").To(c.convert).
 		Doc("Converts currency from one to another").
+		Param(ws.QueryParameter("to", "Comma separated target currencies").
+			DataType("string").AllowMultiple(true)).
+		Param(ws.QueryParameter("date", "Date of exchange rates (YYYY-MM-DD)").DataType("date")).
+		Param(ws.QueryParameter("direction", "Direction of conversion (forward, inverse)").
+			DataType("string")).
+		Param(ws.QueryParameter("fees", "Fee profile charged for conversion").DataType("string")).
 		Writes(converter.ConverterResponse{}))
 
+	ws.Route(ws.GET("/{from}/{to}").To(c.convertPair).
+		Doc("Converts currency to a single target currency").
+		Param(ws.PathParameter("from", "Source currency").DataType("string")).
+		Param(ws.PathParameter("to", "Target currency").DataType("string")).
+		Param(ws.QueryParameter("direction", "Direction of conversion (forward, inverse)").
+			DataType("string")).
+		Param(ws.QueryParameter("fees", "Fee profile charged for conversion").DataType("string")).
+		Writes(converter.PairResponse{}))
+
+	ws.Route(ws.POST("/batch").To(c.convertBatch).
+		Doc("Converts many amounts and currencies at once").
+		Reads(BatchRequest{}).
+		Writes(BatchResponse{}))
+
+	return ws
+}
+
+// TimeSeriesHandler registers time series endpoint and returns its handler
+func (c ConverterService) TimeSeriesHandler() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.
+		Path("/timeseries").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Filter(c.authenticateTenant)
+
+	ws.Route(ws.GET("/").To(c.timeSeries).
+		Doc("Converts currency with exchange rates of every business day in date range").
+		Param(ws.QueryParameter("base", "Source currency").DataType("string")).
+		Param(ws.QueryParameter("symbols", "Comma separated target currencies").
+			DataType("string").AllowMultiple(true)).
+		Param(ws.QueryParameter("start", "Start date (YYYY-MM-DD)").DataType("date")).
+		Param(ws.QueryParameter("end", "End date (YYYY-MM-DD)").DataType("date")).
+		Writes(converter.TimeSeriesResponse{}))
+
 	return ws
 }
 
 func (c ConverterService) convert(request *restful.Request, response *restful.Response) {
 	converterQuery, err := c.parseConverterParameters(request)
 	if err != nil {
-		response.WriteError(http.StatusBadRequest, err)
+		writeError(request, response, err)
 		return
 	}
 
-	converterResponse, err :=
-		converterQuery.Provider.Convert(converterQuery.Amount, converterQuery.Currency)
+	converterResponse, err := c.doConvert(requestContext(request), converterQuery)
 	if err != nil {
-		response.WriteError(http.StatusInternalServerError, err)
+		writeError(request, response, err)
 		return
 	}
 
+	if err := converter.RequireSymbols(converter.Rates(converterResponse.Rates),
+		converterQuery.Currency, converterQuery.Symbols,
+		converterQuery.Provider.Name()); err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	writeCacheHeader(response, converterResponse)
 	response.WriteHeaderAndEntity(http.StatusOK, converterResponse)
 }
 
+func (c ConverterService) convertPair(request *restful.Request, response *restful.Response) {
+	converterQuery, err := c.parsePairParameters(request)
+	if err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	converterResponse, err := c.doConvert(requestContext(request), converterQuery)
+	if err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	writeCacheHeader(response, converterResponse)
+	to := converterQuery.Symbols[0]
+	pairResponse, exists := converterResponse.Pair(to)
+	if !exists {
+		writeError(request, response, common.NewError(common.ErrRateNotFound,
+			"Rate %s/%s is not available in %s provider.", converterQuery.Currency, to,
+			converterQuery.Provider.Name()).WithParameter("to"))
+		return
+	}
+
+	response.WriteHeaderAndEntity(http.StatusOK, pairResponse)
+}
+
+func (c ConverterService) timeSeries(request *restful.Request, response *restful.Response) {
+	// Time series are converted with rates of every day in range, directly and without fees
+	if err := rejectParameters(request, "date", "direction", "fees"); err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	converterQuery, err := c.parseQuery(request, "base", request.QueryParameter("base"),
+		"symbols", c.parseSymbols(request.Request.URL.Query()["symbols"]))
+	if err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	start, end, err := c.parseDateRange(request)
+	if err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	timeSeriesResponse, err := converter.ConvertTimeSeries(requestContext(request),
+		converterQuery.Provider,
+		converter.ConverterRequest{
+			Amount:   converterQuery.Amount,
+			Currency: converterQuery.Currency,
+			Symbols:  converterQuery.Symbols,
+			Rounding: converterQuery.Rounding,
+		}, start, end)
+	if err != nil {
+		writeError(request, response, err)
+		return
+	}
+
+	response.WriteHeaderAndEntity(http.StatusOK, timeSeriesResponse)
+}
+
+func (c ConverterService) doConvert(ctx context.Context,
+	converterQuery *ConverterQuery) (*converter.ConverterResponse, error) {
+
+	request := converter.ConverterRequest{
+		Amount:    converterQuery.Amount,
+		Currency:  converterQuery.Currency,
+		Symbols:   converterQuery.Symbols,
+		Date:      converterQuery.Date,
+		Rounding:  converterQuery.Rounding,
+		Direction: converterQuery.Direction,
+	}
+
+	var response *converter.ConverterResponse
+	var err error
+	if request.Direction == converter.DirectionInverse {
+		response, err = converter.Quote(ctx, converterQuery.Provider, request)
+	} else {
+		response, err = converterQuery.Provider.Convert(ctx, request)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.pricer.Apply(response, converterQuery.Fees)
+	return response, nil
+}
+
+// Parses parameters of general conversion. Target currencies can be given as repeated or comma
+// separated 'to' parameter.
 func (c ConverterService) parseConverterParameters(
 	request *restful.Request) (*ConverterQuery, error) {
 
-	amountParam := request.QueryParameter("amount")
-	amount, err := strconv.ParseFloat(amountParam, 64)
-	if err != nil || amount < 0.0 {
-		log.Printf("Provided amount is invalid or empty: '%s'.", amountParam)
-		return nil, fmt.Errorf("Provided amount is invalid or empty: '%s'.", amountParam)
+	return c.parseQuery(request, "currency", request.QueryParameter("currency"),
+		"to", c.parseSymbols(request.Request.URL.Query()["to"]))
+}
+
+// Splits repeated and comma separated currency parameters into a single list
+func (c ConverterService) parseSymbols(params []string) []string {
+	var symbols []string
+	for _, param := range params {
+		for _, symbol := range strings.Split(param, ",") {
+			if strings.TrimSpace(symbol) != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
 	}
 
-	currency := request.QueryParameter("currency")
-	if currency == "" {
+	return symbols
+}
+
+// Returns error if any of given query parameters, which are not supported by the endpoint, is
+// present in request
+func rejectParameters(request *restful.Request, names ...string) error {
+	query := request.Request.URL.Query()
+	for _, name := range names {
+		if _, exists := query[name]; exists {
+			return common.NewError(common.ErrInvalidParameter,
+				"Parameter %s is not supported by this endpoint.", name).WithParameter(name)
+		}
+	}
+
+	return nil
+}
+
+// Parses required start and end date parameters of time series
+func (c ConverterService) parseDateRange(request *restful.Request) (time.Time, time.Time, error) {
+	start, err := converter.ParseDate(request.QueryParameter("start"))
+	if err != nil {
+		log.Print(err)
+		return time.Time{}, time.Time{}, common.AsError(err).WithParameter("start")
+	}
+
+	end, err := converter.ParseDate(request.QueryParameter("end"))
+	if err != nil {
+		log.Print(err)
+		return time.Time{}, time.Time{}, common.AsError(err).WithParameter("end")
+	}
+
+	if end.After(time.Now()) {
+		end = time.Now()
+	}
+
+	if err := converter.ValidateDateRange(start, end); err != nil {
+		log.Print(err)
+		return time.Time{}, time.Time{}, common.AsError(err).WithParameter("start")
+	}
+
+	return start, end, nil
+}
+
+// Parses parameters of conversion between single pair of currencies given as path parameters
+func (c ConverterService) parsePairParameters(request *restful.Request) (*ConverterQuery, error) {
+	return c.parseQuery(request, "from", request.PathParameter("from"),
+		"to", []string{request.PathParameter("to")})
+}
+
+// Raw parameters of a conversion together with names of currency parameters reported in errors
+type queryParams struct {
+	amount       string
+	currencyName string
+	currency     string
+	symbolsName  string
+	symbols      []string
+	date         string
+	rounding     string
+	direction    string
+	fees         string
+	provider     string
+}
+
+// Parses parameters shared by all conversions. Names of currency parameters are reported in
+// returned errors.
+func (c ConverterService) parseQuery(request *restful.Request, currencyName string,
+	currencyParam string, symbolsName string, symbolParams []string) (*ConverterQuery, error) {
+
+	return c.parseParams(queryParams{
+		amount:       request.QueryParameter("amount"),
+		currencyName: currencyName,
+		currency:     currencyParam,
+		symbolsName:  symbolsName,
+		symbols:      symbolParams,
+		date:         request.QueryParameter("date"),
+		rounding:     request.QueryParameter("rounding"),
+		direction:    request.QueryParameter("direction"),
+		fees:         request.QueryParameter("fees"),
+		provider:     request.QueryParameter("provider"),
+	})
+}
+
+// Parses raw parameters of a conversion. Default provider is used if none is given.
+func (c ConverterService) parseParams(params queryParams) (*ConverterQuery, error) {
+	amount, err := common.ParseDecimal(params.amount)
+	if err != nil || amount.Sign() < 0 {
+		log.Printf("Provided amount is invalid or empty: '%s'.", params.amount)
+		return nil, common.NewError(common.ErrInvalidAmount,
+			"Provided amount is invalid or empty: '%s'.", params.amount).WithParameter("amount")
+	}
+
+	if params.currency == "" {
 		log.Println("Currency parameter can not be empty.")
-		return nil, errors.New("Currency parameter can not be empty.")
+		return nil, common.NewError(common.ErrInvalidCurrency,
+			"Currency parameter can not be empty.").WithParameter(params.currencyName)
+	}
+
+	currency, err := common.NormalizeCurrency(params.currency)
+	if err != nil {
+		log.Print(err)
+		return nil, common.AsError(err).WithParameter(params.currencyName)
+	}
+
+	symbols := make([]string, 0, len(params.symbols))
+	for _, symbolParam := range params.symbols {
+		symbol, err := common.NormalizeCurrency(symbolParam)
+		if err != nil {
+			log.Print(err)
+			return nil, common.AsError(err).WithParameter(params.symbolsName)
+		}
+
+		symbols = append(symbols, symbol)
 	}
 
-	var provider converter.ConverterProvider
-	providerName := request.QueryParameter("provider")
-	provider = c.getProvider(providerName)
-	if provider == nil {
-		provider = c.getDefaultProvider()
-		log.Printf("Provider is either empty or invalid. Falling back to default provider: %s",
-			provider.Name())
+	var date time.Time
+	if dateParam := params.date; dateParam != "" {
+		date, err = converter.ParseDate(dateParam)
+		if err != nil {
+			log.Print(err)
+			return nil, common.AsError(err).WithParameter("date")
+		}
+
+		if date.After(time.Now()) {
+			log.Printf("Provided date can not be in the future: '%s'.", dateParam)
+			return nil, common.NewError(common.ErrInvalidParameter,
+				"Provided date can not be in the future: '%s'.", dateParam).WithParameter("date")
+		}
+	}
+
+	rounding := c.rounding
+	if roundingParam := params.rounding; roundingParam != "" {
+		rounding, err = common.ParseRoundingMode(roundingParam)
+		if err != nil {
+			log.Print(err)
+			return nil, common.AsError(err).WithParameter("rounding")
+		}
+	}
+
+	direction := strings.ToLower(strings.TrimSpace(params.direction))
+	switch direction {
+	case "":
+		direction = converter.DirectionForward
+	case converter.DirectionForward, converter.DirectionInverse:
+	default:
+		log.Printf("Direction %s is not supported.", params.direction)
+		return nil, common.NewError(common.ErrInvalidParameter,
+			"Direction %s is not supported. Supported directions: %s, %s.", params.direction,
+			converter.DirectionForward, converter.DirectionInverse).WithParameter("direction")
+	}
+
+	fees, err := c.pricer.FeeProfile(params.fees)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	provider := c.getDefaultProvider()
+	if params.provider != "" {
+		if provider = c.getProvider(params.provider); provider == nil {
+			log.Printf("Provider %s does not exist.", params.provider)
+			return nil, common.NewError(common.ErrInvalidParameter,
+				"Provider %s does not exist.", params.provider).WithParameter("provider")
+		}
+	}
+
+	return &ConverterQuery{Amount: amount, Currency: currency, Symbols: symbols, Date: date,
+		Rounding: rounding, Direction: direction, Fees: fees, Provider: provider}, nil
+}
+
+// Identifies tenant on whose behalf request is made by API key given in X-Api-Key header. Requests
+// without a key are not made on behalf of any tenant, requests with unknown key are rejected.
+func (c ConverterService) authenticateTenant(request *restful.Request,
+	response *restful.Response, chain *restful.FilterChain) {
+
+	key := request.HeaderParameter(tenantKeyHeader)
+	if len(key) > 0 {
+		tenant, exists := c.tenants[key]
+		if !exists {
+			log.Printf("Request with unknown API key rejected")
+			writeError(request, response, common.NewError(common.ErrUnauthorized,
+				"API key is invalid."))
+			return
+		}
+
+		request.SetAttribute(tenantAttribute, tenant)
 	}
 
-	return &ConverterQuery{Amount: amount, Currency: currency, Provider: provider}, nil
+	chain.ProcessFilter(request, response)
 }
 
-// NewConverterService returns initialized ConverterService object
-func NewConverterService() ConverterService {
-	return ConverterService{providers: converter.GetProviders()}
+// Returns context of given request carrying its authenticated tenant, so that overrides scoped to
+// the tenant are applied
+func requestContext(request *restful.Request) context.Context {
+	tenant, _ := request.Attribute(tenantAttribute).(string)
+	return converter.WithTenant(request.Request.Context(), tenant)
+}
+
+// Writes error as a response body with status matching its code, see common.AsError. Providers
+// with open circuit are reported with Retry-After header. Nothing is written if client is already
+// gone.
+func writeError(request *restful.Request, response *restful.Response, err error) {
+	if request.Request.Context().Err() == context.Canceled {
+		log.Printf("Client disconnected before rates were served: %s", err)
+		return
+	}
+
+	if circuitErr, ok := err.(*converter.CircuitOpenError); ok {
+		response.AddHeader("Retry-After", strconv.Itoa(circuitErr.RetryAfterSeconds()))
+	}
+
+	clientErr := common.AsError(err)
+	response.WriteHeaderAndEntity(clientErr.Status(), clientErr)
+}
+
+// Reports whether exchange rates were served from cache in X-Cache response header
+func writeCacheHeader(response *restful.Response, converterResponse *converter.ConverterResponse) {
+	if converterResponse.Cache != "" {
+		response.AddHeader("X-Cache", converterResponse.Cache)
+	}
+}
+
+// NewConverterService returns initialized ConverterService object using given providers. Provider
+// with given default name and given rounding mode are used for requests that do not specify them.
+// Default provider has to be one of given providers. Converted amounts are priced by given pricer.
+// Requests carrying one of given API keys are made on behalf of tenant of the key.
+func NewConverterService(providers []converter.ConverterProvider, defaultProvider string,
+	rounding common.RoundingMode, pricer converter.Pricer,
+	tenants map[string]string) ConverterService {
+
+	return ConverterService{providers: providers, defaultProvider: defaultProvider,
+		rounding: rounding, pricer: pricer, tenants: tenants}
 }
@@ -0,0 +1,75 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package currency
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/floreks/go-currency/common"
+)
+
+// CurrencyList is a structure returned by currency service. Needed for correct xml response.
+type CurrencyList struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"currencies"`
+
+	// Currencies known to the ISO 4217 registry
+	Currencies []common.Currency `json:"currencies" xml:"currency"`
+}
+
+// CurrencyService exposes embedded ISO 4217 currency registry.
+type CurrencyService struct{}
+
+// Handler registers endpoints and returns handler for currency service
+func (c CurrencyService) Handler() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.
+		Path("/currencies").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML)
+
+	ws.Route(ws.GET("/").To(c.list).
+		Doc("Lists all known currencies").
+		Writes(CurrencyList{}))
+
+	ws.Route(ws.GET("/{code}").To(c.get).
+		Doc("Returns currency with given ISO 4217 code").
+		Param(ws.PathParameter("code", "ISO 4217 currency code").DataType("string")).
+		Writes(common.Currency{}))
+
+	return ws
+}
+
+func (c CurrencyService) list(request *restful.Request, response *restful.Response) {
+	response.WriteHeaderAndEntity(http.StatusOK, CurrencyList{Currencies: common.Currencies()})
+}
+
+func (c CurrencyService) get(request *restful.Request, response *restful.Response) {
+	code, err := common.NormalizeCurrency(request.PathParameter("code"))
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusNotFound, common.NewError(common.ErrNotFound,
+			"%s", err).WithParameter("code"))
+		return
+	}
+
+	currency, _ := common.LookupCurrency(code)
+
+	response.WriteHeaderAndEntity(http.StatusOK, currency)
+}
+
+// NewCurrencyService returns initialized CurrencyService object
+func NewCurrencyService() CurrencyService {
+	return CurrencyService{}
+}
@@ -0,0 +1,177 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/floreks/go-currency/common"
+	"github.com/floreks/go-currency/provider/converter"
+)
+
+// ProviderInfo describes a registered exchange rates provider
+type ProviderInfo struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"provider"`
+
+	// Name of the provider used in 'provider' query parameter
+	Name string `json:"name" xml:"name"`
+
+	// Default is true if provider is used for requests that do not select one
+	Default bool `json:"default" xml:"default"`
+
+	converter.Capabilities
+
+	// Health of the provider: unknown, up or down
+	Health string `json:"health" xml:"health"`
+
+	// LastFetch - time of the last successful fetch of rates in RFC 3339 format
+	LastFetch string `json:"lastFetch,omitempty" xml:"lastFetch,omitempty"`
+
+	// LastError - error of the last failed fetch of rates
+	LastError string `json:"lastError,omitempty" xml:"lastError,omitempty"`
+
+	// Circuit - state of circuit breaker guarding the provider: closed, open or half-open
+	Circuit string `json:"circuit,omitempty" xml:"circuit,omitempty"`
+
+	// Refresh - outcome of background refreshes of latest rates, if they are scheduled
+	Refresh *RefreshInfo `json:"refresh,omitempty" xml:"refresh,omitempty"`
+}
+
+// RefreshInfo describes background refreshes of a provider. Times are in RFC 3339 format.
+type RefreshInfo struct {
+	// LastRun - time when the last refresh finished
+	LastRun string `json:"lastRun,omitempty" xml:"lastRun,omitempty"`
+
+	// LastSuccess - time of the last refresh that fetched rates of every base currency
+	LastSuccess string `json:"lastSuccess,omitempty" xml:"lastSuccess,omitempty"`
+
+	// NextRun - time of the next scheduled refresh
+	NextRun string `json:"nextRun,omitempty" xml:"nextRun,omitempty"`
+
+	// Bases - number of base currencies whose rates are prefetched
+	Bases int `json:"bases" xml:"bases"`
+
+	// LastError - errors of the last refresh
+	LastError string `json:"lastError,omitempty" xml:"lastError,omitempty"`
+}
+
+// ProviderList is a structure returned by provider service. Needed for correct xml response.
+type ProviderList struct {
+	// XMLName needed for correct xml response
+	XMLName xml.Name `json:"-" xml:"providers"`
+
+	// Providers registered in the application
+	Providers []ProviderInfo `json:"providers" xml:"provider"`
+}
+
+// ProviderService describes registered exchange rates providers.
+type ProviderService struct {
+	providers []converter.ConverterProvider
+
+	// Name of the provider used when request does not select one
+	defaultProvider string
+
+	// Status of providers by their name
+	monitors map[string]converter.StatusReporter
+
+	// Background refreshes by name of refreshed provider
+	refreshers map[string]converter.RefreshReporter
+}
+
+// Handler registers endpoints and returns handler for provider service
+func (p ProviderService) Handler() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.
+		Path("/providers").
+		Consumes(restful.MIME_JSON, restful.MIME_XML).
+		Produces(restful.MIME_JSON, restful.MIME_XML)
+
+	ws.Route(ws.GET("/").To(p.list).
+		Doc("Lists all registered providers").
+		Writes(ProviderList{}))
+
+	ws.Route(ws.GET("/{name}").To(p.get).
+		Doc("Returns provider with given name").
+		Param(ws.PathParameter("name", "Name of the provider").DataType("string")).
+		Writes(ProviderInfo{}))
+
+	return ws
+}
+
+func (p ProviderService) list(request *restful.Request, response *restful.Response) {
+	list := ProviderList{Providers: make([]ProviderInfo, 0, len(p.providers))}
+	for _, provider := range p.providers {
+		list.Providers = append(list.Providers, p.info(provider))
+	}
+
+	response.WriteHeaderAndEntity(http.StatusOK, list)
+}
+
+func (p ProviderService) get(request *restful.Request, response *restful.Response) {
+	name := request.PathParameter("name")
+	for _, provider := range p.providers {
+		if provider.Name() == name {
+			response.WriteHeaderAndEntity(http.StatusOK, p.info(provider))
+			return
+		}
+	}
+
+	response.WriteHeaderAndEntity(http.StatusNotFound, common.NewError(common.ErrNotFound,
+		"Provider %s does not exist.", name).WithParameter("name"))
+}
+
+// Describes given provider. Health is unknown unless status of provider is monitored.
+func (p ProviderService) info(provider converter.ConverterProvider) ProviderInfo {
+	info := ProviderInfo{Name: provider.Name(), Default: provider.Name() == p.defaultProvider,
+		Capabilities: provider.Capabilities(), Health: converter.HealthUnknown}
+
+	if monitor, exists := p.monitors[provider.Name()]; exists {
+		status := monitor.Status()
+		info.Health = status.Health
+		info.LastError = status.LastError
+		info.LastFetch = formatTime(status.LastSuccess)
+		info.Circuit = status.Circuit
+	}
+
+	if refresher, exists := p.refreshers[provider.Name()]; exists {
+		status := refresher.RefreshStatus()
+		info.Refresh = &RefreshInfo{LastRun: formatTime(status.LastRun),
+			LastSuccess: formatTime(status.LastSuccess), NextRun: formatTime(status.NextRun),
+			Bases: status.Bases, LastError: status.LastError}
+	}
+
+	return info
+}
+
+// Formats given time in RFC 3339 format, zero time is formatted as empty string
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// NewProviderService returns initialized ProviderService object describing given providers, their
+// monitored status and background refreshes of some of them
+func NewProviderService(providers []converter.ConverterProvider, defaultProvider string,
+	monitors map[string]converter.StatusReporter,
+	refreshers map[string]converter.RefreshReporter) ProviderService {
+
+	return ProviderService{providers: providers, defaultProvider: defaultProvider,
+		monitors: monitors, refreshers: refreshers}
+}